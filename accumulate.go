@@ -0,0 +1,232 @@
+package healpix
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// The running statistics of the samples binned into one pixel.
+type accumulatorCell struct {
+	count       int
+	sum         float64
+	weight      float64
+	weightedSum float64
+	mean        float64 // running mean and sum of squared deviations, for a stable variance
+	m2          float64
+	min         float64
+	max         float64
+}
+
+func (c *accumulatorCell) add(weight float64, value float64) {
+	c.count++
+	c.sum += value
+	c.weight += weight
+	c.weightedSum += weight * value
+	delta := value - c.mean
+	c.mean += delta / float64(c.count)
+	c.m2 += delta * (value - c.mean)
+	if c.count == 1 {
+		c.min, c.max = value, value
+	} else {
+		c.min = math.Min(c.min, value)
+		c.max = math.Max(c.max, value)
+	}
+}
+
+// Combine the statistics of another cell into this one, using the pairwise update of Chan et al. for the
+// mean and squared deviations.
+func (c *accumulatorCell) merge(other *accumulatorCell) {
+	if other.count == 0 {
+		return
+	}
+	if c.count == 0 {
+		*c = *other
+		return
+	}
+	n := float64(c.count + other.count)
+	delta := other.mean - c.mean
+	c.m2 += other.m2 + delta*delta*float64(c.count)*float64(other.count)/n
+	c.mean += delta * float64(other.count) / n
+	c.count += other.count
+	c.sum += other.sum
+	c.weight += other.weight
+	c.weightedSum += other.weightedSum
+	c.min = math.Min(c.min, other.min)
+	c.max = math.Max(c.max, other.max)
+}
+
+// Bins a stream of weighted samples into the pixels of a HEALPix map, keeping the statistics needed for
+// count, sum, mean, extreme, variance and weighted mean maps. Only the pixels that receive samples take up
+// memory, so an accumulator can bin sparse data at fine orders. An Accumulator is not safe for concurrent use;
+// give each goroutine its own partial accumulator from NewPartial and Merge them, or use AccumulateParallel.
+type Accumulator struct {
+	hp     Healpix
+	scheme HealpixScheme
+	cells  map[uint]*accumulatorCell
+}
+
+// Create an empty accumulator binning samples into the pixels of the given HEALPix map, producing maps
+// indexed in the given scheme.
+func NewAccumulator(hp Healpix, scheme HealpixScheme) *Accumulator {
+	return &Accumulator{hp, scheme, map[uint]*accumulatorCell{}}
+}
+
+// Create an empty accumulator with the same map and scheme as this one, to bin part of the samples and later
+// merge back.
+func (a *Accumulator) NewPartial() *Accumulator {
+	return NewAccumulator(a.hp, a.scheme)
+}
+
+// The HEALPix map the samples are binned into.
+func (a *Accumulator) Healpix() Healpix {
+	return a.hp
+}
+
+// The numbering scheme of the maps produced.
+func (a *Accumulator) Scheme() HealpixScheme {
+	return a.scheme
+}
+
+// Bin a sample with the given weight and value into the pixel containing the position.
+func (a *Accumulator) Add(where Where, weight float64, value float64) {
+	p := where.PixelId(a.hp, a.scheme)
+	c, found := a.cells[p]
+	if !found {
+		c = &accumulatorCell{}
+		a.cells[p] = c
+	}
+	c.add(weight, value)
+}
+
+// Combine the samples binned by the other accumulators into this one, in the order given. Counts and
+// extremes do not depend on the order of merging, while the sums and the statistics derived from them may
+// differ in the last bits of floating point precision; merging the same partial accumulators in the same order
+// always gives the same result. The others are left unchanged. Panics if an accumulator bins into a different map or
+// scheme, or is this accumulator itself, whose samples would be counted twice.
+func (a *Accumulator) Merge(others ...*Accumulator) {
+	for _, other := range others {
+		if other == a {
+			panic("healpix: cannot merge an accumulator into itself")
+		}
+		if other.hp.FaceSidePixels() != a.hp.FaceSidePixels() || other.scheme != a.scheme {
+			panic(fmt.Sprintf("healpix: cannot merge an accumulator for nside %d scheme %d into one for nside %d scheme %d",
+				other.hp.FaceSidePixels(), other.scheme, a.hp.FaceSidePixels(), a.scheme))
+		}
+		for p, oc := range other.cells {
+			c, found := a.cells[p]
+			if !found {
+				c = &accumulatorCell{}
+				a.cells[p] = c
+			}
+			c.merge(oc)
+		}
+	}
+}
+
+// The number of pixels that have received at least one sample.
+func (a *Accumulator) Len() int {
+	return len(a.cells)
+}
+
+// Return a map of the given statistic of each pixel, with the empty value for pixels without samples.
+func (a *Accumulator) statMap(empty float64, stat func(c *accumulatorCell) float64) []float64 {
+	m := make([]float64, a.hp.Pixels())
+	for p := range m {
+		m[p] = empty
+	}
+	for p, c := range a.cells {
+		m[p] = stat(c)
+	}
+	return m
+}
+
+// The number of samples binned into each pixel.
+func (a *Accumulator) Count() []int {
+	m := make([]int, a.hp.Pixels())
+	for p, c := range a.cells {
+		m[p] = c.count
+	}
+	return m
+}
+
+// The sum of the values binned into each pixel, which is 0 for pixels without samples.
+func (a *Accumulator) Sum() []float64 {
+	return a.statMap(0, func(c *accumulatorCell) float64 { return c.sum })
+}
+
+// The sum of the weights binned into each pixel, which is 0 for pixels without samples.
+func (a *Accumulator) Weight() []float64 {
+	return a.statMap(0, func(c *accumulatorCell) float64 { return c.weight })
+}
+
+// The unweighted mean of the values binned into each pixel, which is NaN for pixels without samples.
+func (a *Accumulator) Mean() []float64 {
+	return a.statMap(math.NaN(), func(c *accumulatorCell) float64 { return c.mean })
+}
+
+// The smallest value binned into each pixel, which is NaN for pixels without samples.
+func (a *Accumulator) Min() []float64 {
+	return a.statMap(math.NaN(), func(c *accumulatorCell) float64 { return c.min })
+}
+
+// The largest value binned into each pixel, which is NaN for pixels without samples.
+func (a *Accumulator) Max() []float64 {
+	return a.statMap(math.NaN(), func(c *accumulatorCell) float64 { return c.max })
+}
+
+// The unweighted population variance of the values binned into each pixel, which is the mean squared
+// deviation from the mean, and is NaN for pixels without samples.
+func (a *Accumulator) Variance() []float64 {
+	return a.statMap(math.NaN(), func(c *accumulatorCell) float64 { return c.m2 / float64(c.count) })
+}
+
+// The mean of the values binned into each pixel weighted by the weights of the samples, which is NaN for
+// pixels without samples or whose weights sum to 0.
+func (a *Accumulator) WeightedMean() []float64 {
+	return a.statMap(math.NaN(), func(c *accumulatorCell) float64 {
+		if c.weight == 0 {
+			return math.NaN()
+		}
+		return c.weightedSum / c.weight
+	})
+}
+
+// The number of samples AccumulateParallel bins into each partial accumulator.
+const accumulateChunkSize = 4096
+
+// Bin n samples into a new accumulator for the given map and scheme, calling sample with each index from 0
+// to n-1 to get the position, weight and value of the sample. The indices are split into contiguous chunks of
+// a fixed size, which the worker goroutines bin into partial accumulators, and the partial accumulators are
+// merged in order of their chunks. The result is therefore the same to the bit for any number of workers and
+// any scheduling of the goroutines, and identical to adding the samples in order to one accumulator when there
+// are no more than 4096 of them. Values of workers <= 0 use runtime.GOMAXPROCS(0). Sample is called
+// concurrently, but only once for each index.
+func AccumulateParallel(hp Healpix, scheme HealpixScheme, n int, workers int, sample func(i int) (Where, float64, float64)) *Accumulator {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	result := NewAccumulator(hp, scheme)
+	chunks := (n + accumulateChunkSize - 1) / accumulateChunkSize
+	// bin up to one chunk per worker at a time, so only that many partial accumulators are held at once
+	for first := 0; first < chunks; first += workers {
+		partials := make([]*Accumulator, min(workers, chunks-first))
+		var wg sync.WaitGroup
+		for k := range partials {
+			partials[k] = NewAccumulator(hp, scheme)
+			start := (first + k) * accumulateChunkSize
+			wg.Add(1)
+			go func(partial *Accumulator, start int, end int) {
+				defer wg.Done()
+				for i := start; i < end; i++ {
+					where, weight, value := sample(i)
+					partial.Add(where, weight, value)
+				}
+			}(partials[k], start, min(start+accumulateChunkSize, n))
+		}
+		wg.Wait()
+		result.Merge(partials...)
+	}
+	return result
+}
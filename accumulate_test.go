@@ -0,0 +1,162 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+type accumulatorSample struct {
+	where  SphereCoordinate
+	weight float64
+	value  float64
+}
+
+func randomSamples(rng *rand.Rand, n int) []accumulatorSample {
+	samples := make([]accumulatorSample, n)
+	for i := range samples {
+		samples[i] = accumulatorSample{randomPosition(rng), rng.Float64() * 2, rng.NormFloat64()*3 + 10}
+	}
+	return samples
+}
+
+func TestAccumulator(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		hp := New(NewHealpixOrder(2))
+		samples := randomSamples(rng, 2000)
+		acc := NewAccumulator(hp, scheme)
+		binned := make([][]accumulatorSample, hp.Pixels())
+		for _, s := range samples {
+			acc.Add(s.where, s.weight, s.value)
+			p := s.where.PixelId(hp, scheme)
+			binned[p] = append(binned[p], s)
+		}
+		count, sum, weight := acc.Count(), acc.Sum(), acc.Weight()
+		mean, low, high := acc.Mean(), acc.Min(), acc.Max()
+		variance, weighted := acc.Variance(), acc.WeightedMean()
+		filled := 0
+		for p, bin := range binned {
+			if len(bin) == 0 {
+				if count[p] != 0 || sum[p] != 0 || weight[p] != 0 || !math.IsNaN(mean[p]) || !math.IsNaN(low[p]) ||
+					!math.IsNaN(high[p]) || !math.IsNaN(variance[p]) || !math.IsNaN(weighted[p]) {
+					t.Fatalf("pixel %d: expected empty statistics", p)
+				}
+				continue
+			}
+			filled++
+			var s, w, ws, lo, hi float64 = 0, 0, 0, math.Inf(1), math.Inf(-1)
+			for _, b := range bin {
+				s += b.value
+				w += b.weight
+				ws += b.weight * b.value
+				lo = min(lo, b.value)
+				hi = max(hi, b.value)
+			}
+			m := s / float64(len(bin))
+			v := 0.0
+			for _, b := range bin {
+				v += (b.value - m) * (b.value - m)
+			}
+			v /= float64(len(bin))
+			if count[p] != len(bin) || low[p] != lo || high[p] != hi {
+				t.Fatalf("pixel %d: expected count %d min %v max %v, got %d %v %v", p, len(bin), lo, hi, count[p], low[p], high[p])
+			}
+			if !withinTolerance(sum[p], s, 1e-12) || !withinTolerance(weight[p], w, 1e-12) ||
+				!withinTolerance(mean[p], m, 1e-12) || !withinTolerance(variance[p], v, 1e-9) ||
+				!withinTolerance(weighted[p], ws/w, 1e-12) {
+				t.Fatalf("pixel %d: statistics differ from direct computation", p)
+			}
+		}
+		if acc.Len() != filled {
+			t.Errorf("expected %d pixels with samples, got %d", filled, acc.Len())
+		}
+	}
+}
+
+func TestAccumulatorMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(48))
+	hp := New(NewHealpixOrder(1))
+	samples := randomSamples(rng, 1000)
+	whole := NewAccumulator(hp, NestScheme)
+	parts := []*Accumulator{whole.NewPartial(), whole.NewPartial(), whole.NewPartial()}
+	for i, s := range samples {
+		whole.Add(s.where, s.weight, s.value)
+		parts[i%len(parts)].Add(s.where, s.weight, s.value)
+	}
+	merged := NewAccumulator(New(NewHealpixSide(2)), NestScheme)
+	merged.Merge(parts...)
+	if !slices.Equal(merged.Count(), whole.Count()) || !slices.Equal(merged.Min(), whole.Min()) ||
+		!slices.Equal(merged.Max(), whole.Max()) {
+		t.Errorf("expected merged counts and extremes to equal those of a single accumulator")
+	}
+	for _, stat := range []func(*Accumulator) []float64{
+		(*Accumulator).Sum, (*Accumulator).Weight, (*Accumulator).Mean, (*Accumulator).Variance, (*Accumulator).WeightedMean,
+	} {
+		a, b := stat(merged), stat(whole)
+		for p := range a {
+			if !withinTolerance(a[p], b[p], 1e-12) {
+				t.Fatalf("pixel %d: merged statistic %v differs from %v", p, a[p], b[p])
+			}
+		}
+	}
+}
+
+func TestAccumulateParallel(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	hp := New(NewHealpixOrder(3))
+	samples := randomSamples(rng, 5*accumulateChunkSize+100)
+	sample := func(i int) (Where, float64, float64) {
+		return samples[i].where, samples[i].weight, samples[i].value
+	}
+	identical := func(a float64, b float64) bool {
+		return a == b || math.IsNaN(a) && math.IsNaN(b)
+	}
+	serial := AccumulateParallel(hp, RingScheme, len(samples), 1, sample)
+	for _, workers := range []int{0, 2, 4, 16} {
+		for run := 0; run < 3; run++ {
+			// the results are identical to the bit for any number of workers
+			parallel := AccumulateParallel(hp, RingScheme, len(samples), workers, sample)
+			if !slices.Equal(parallel.Count(), serial.Count()) ||
+				!slices.EqualFunc(parallel.Variance(), serial.Variance(), identical) ||
+				!slices.EqualFunc(parallel.WeightedMean(), serial.WeightedMean(), identical) {
+				t.Fatalf("%d workers, run %d: expected the same result as 1 worker", workers, run)
+			}
+		}
+	}
+
+	// a single chunk gives the same result as adding the samples in order
+	direct := NewAccumulator(hp, RingScheme)
+	for _, s := range samples[:100] {
+		direct.Add(s.where, s.weight, s.value)
+	}
+	if chunk := AccumulateParallel(hp, RingScheme, 100, 4, sample); !slices.EqualFunc(chunk.Variance(), direct.Variance(), identical) {
+		t.Errorf("expected a single chunk to match adding the samples directly")
+	}
+	if empty := AccumulateParallel(hp, RingScheme, 0, 0, sample); empty.Len() != 0 {
+		t.Errorf("expected no pixels from no samples, got %d", empty.Len())
+	}
+}
+
+func TestAccumulatorMergePanics(t *testing.T) {
+	a := NewAccumulator(New(NewHealpixOrder(1)), NestScheme)
+	testCases := []struct {
+		name  string
+		other *Accumulator
+	}{
+		{"different map", NewAccumulator(New(NewHealpixOrder(2)), NestScheme)},
+		{"different scheme", NewAccumulator(New(NewHealpixOrder(1)), RingScheme)},
+		{"itself", a},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			a.Merge(tc.other)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package healpix
+
+import (
+	"fmt"
+	"math"
+)
+
+// The shape of the taper Apodize applies to a mask near its edges.
+type Taper int
+
+const (
+	// The C1 taper of NaMaster, x - sin(2 pi x) / 2 pi, which rises from 0 at the edge of the mask to 1 at the
+	// apodization radius with a continuous first derivative.
+	C1Taper Taper = iota
+	// The C2 taper of NaMaster, (1 - cos(pi x)) / 2, which rises from 0 at the edge of the mask to 1 at the
+	// apodization radius with a continuous first and second derivative.
+	C2Taper
+	// The complement of a Gaussian, 1 - exp(-d^2 / 2 radius^2), where the apodization radius is the standard
+	// deviation. It is computed out to five standard deviations, where it is within 4e-6 of 1, and is 1
+	// beyond.
+	GaussianTaper
+)
+
+// How far from the edge of the mask the taper is computed, in multiples of the apodization radius.
+var taperExtents = [3]float64{1, 1, 5}
+
+// The weight of the taper at the given distance from the edge of the mask. The C1 and C2 tapers use the
+// normalized chord distance of NaMaster, which is close to distance / radius.
+func (t Taper) weight(distance float64, radius float64) float64 {
+	if math.IsInf(distance, 1) {
+		return 1
+	}
+	switch t {
+	case C1Taper, C2Taper:
+		x := math.Sqrt((1 - math.Cos(distance)) / (1 - math.Cos(radius)))
+		if x >= 1 {
+			return 1
+		}
+		if t == C1Taper {
+			return x - math.Sin(2*math.Pi*x)/(2*math.Pi)
+		}
+		return (1 - math.Cos(math.Pi*x)) / 2
+	default:
+		return 1 - math.Exp(-distance*distance/(2*radius*radius))
+	}
+}
+
+// Return, for each pixel kept by the mask, the angular distance from its center to the center of the nearest
+// masked pixel, in radians. The mask is indexed in the given scheme and holds true for the kept pixels, like
+// the binary masks of healpy; masked pixels have distance 0. Distances beyond maxDistance, and all distances
+// when no pixel is masked, are +Inf. The distances are exact, but the cost of each pixel grows with the square
+// root of its distance from the edge of the mask in pixels, so limit maxDistance to what is needed on fine
+// maps; math.Inf(1) computes every distance. Panics if the mask does not have one value per pixel.
+func DistanceTransform(hp Healpix, mask []bool, scheme HealpixScheme, maxDistance float64) []float64 {
+	checkMaskSize(hp, mask)
+	masked := func(p uint) bool { return !mask[p] }
+	distances := make([]float64, len(mask))
+	for p, kept := range mask {
+		if kept {
+			distances[p] = math.Inf(1)
+		}
+	}
+	boundary := setBoundary(hp, scheme, masked, maskPixels(mask, false))
+	for p, distance := range nearestDistances(hp, scheme, masked, boundary, maxDistance) {
+		distances[p] = distance
+	}
+	return distances
+}
+
+// Return the weights of the mask apodized with the given taper: 0 for masked pixels, rising with the distance
+// from the edge of the mask to 1 for kept pixels at least the apodization radius (in radians) away from any
+// masked pixel. The mask is indexed in the given scheme and holds true for the kept pixels. Panics if the
+// mask does not have one value per pixel, the radius is not positive, or the taper is invalid.
+func Apodize(hp Healpix, mask []bool, scheme HealpixScheme, radius float64, taper Taper) []float64 {
+	if radius <= 0 {
+		panic(fmt.Sprintf("healpix: apodization radius %v must be positive", radius))
+	}
+	if taper < C1Taper || taper > GaussianTaper {
+		panic(fmt.Sprintf("healpix: invalid taper %d", taper))
+	}
+	distances := DistanceTransform(hp, mask, scheme, taperExtents[taper]*radius)
+	weights := make([]float64, len(distances))
+	for p, distance := range distances {
+		if mask[p] {
+			weights[p] = taper.weight(distance, radius)
+		}
+	}
+	return weights
+}
@@ -0,0 +1,159 @@
+package healpix
+
+import (
+	"cmp"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// The distance from each pixel to the nearest masked pixel, by checking every masked pixel.
+func bruteForceDistances(hp Healpix, mask []bool, scheme HealpixScheme) []float64 {
+	centers := make([]Vector, len(mask))
+	for p, center := range hp.AllPixelCenters(scheme) {
+		centers[p] = center.ToVector()
+	}
+	distances := make([]float64, len(mask))
+	for q, kept := range mask {
+		if !kept {
+			continue
+		}
+		distances[q] = math.Inf(1)
+		for p, k := range mask {
+			if !k {
+				distances[q] = min(distances[q], angleBetween(centers[p], centers[q]))
+			}
+		}
+	}
+	return distances
+}
+
+func TestDistanceTransform(t *testing.T) {
+	rng := rand.New(rand.NewSource(45))
+	testCases := []struct {
+		name        string
+		order       int
+		scheme      HealpixScheme
+		maxDistance float64
+	}{
+		{"unlimited nest", 3, NestScheme, math.Inf(1)},
+		{"unlimited ring", 3, RingScheme, math.Inf(1)},
+		{"limited", 4, NestScheme, 0.2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			// keep a few large regions, so the masked pixels surround them
+			mask := randomBlobMask(hp, tc.scheme, rng)
+			expected := bruteForceDistances(hp, mask, tc.scheme)
+			distances := DistanceTransform(hp, mask, tc.scheme, tc.maxDistance)
+			for p := range mask {
+				want := expected[p]
+				if want > tc.maxDistance {
+					want = math.Inf(1)
+				}
+				if distances[p] != want {
+					t.Fatalf("pixel %d: expected distance %v, got %v", p, want, distances[p])
+				}
+			}
+		})
+	}
+}
+
+func TestDistanceTransformNothingMasked(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	mask := make([]bool, hp.Pixels())
+	for p := range mask {
+		mask[p] = true
+	}
+	for p, distance := range DistanceTransform(hp, mask, RingScheme, math.Inf(1)) {
+		if !math.IsInf(distance, 1) {
+			t.Fatalf("pixel %d: expected infinite distance, got %v", p, distance)
+		}
+	}
+}
+
+func TestTaperWeight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		taper    Taper
+		distance float64
+		weight   float64
+	}{
+		{"C1 edge", C1Taper, 0, 0},
+		{"C1 middle", C1Taper, 0.05, 0.5},
+		{"C1 radius", C1Taper, 0.1, 1},
+		{"C1 beyond", C1Taper, 0.3, 1},
+		{"C2 edge", C2Taper, 0, 0},
+		{"C2 middle", C2Taper, 0.05, 0.5},
+		{"C2 radius", C2Taper, 0.1, 1},
+		{"Gaussian edge", GaussianTaper, 0, 0},
+		{"Gaussian one sigma", GaussianTaper, 0.1, 1 - math.Exp(-0.5)},
+		{"Gaussian far", GaussianTaper, math.Inf(1), 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the normalized chord distance is slightly off distance / radius
+			if w := tc.taper.weight(tc.distance, 0.1); math.Abs(w-tc.weight) > 1e-3 {
+				t.Errorf("expected weight %v, got %v", tc.weight, w)
+			}
+		})
+	}
+}
+
+func TestApodize(t *testing.T) {
+	hp := New(NewHealpixOrder(5))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		for _, taper := range []Taper{C1Taper, C2Taper, GaussianTaper} {
+			center := NewLatLonCoordinate(0.2, 3)
+			mask := discMask(hp, center, 0.6, scheme)
+			radius := 0.15
+			weights := Apodize(hp, mask, scheme, radius, taper)
+			distances := DistanceTransform(hp, mask, scheme, math.Inf(1))
+			for p, kept := range mask {
+				expected := 0.0
+				if kept {
+					expected = taper.weight(distances[p], radius)
+					if taper == GaussianTaper && distances[p] > 5*radius {
+						expected = 1
+					}
+				}
+				if weights[p] != expected {
+					t.Fatalf("taper %d pixel %d: expected weight %v, got %v", taper, p, expected, weights[p])
+				}
+			}
+			// the weights rise toward the middle of the disc
+			order := QueryDisc(hp, center, 0.6, scheme, false)
+			slices.SortFunc(order, func(a uint, b uint) int { return cmp.Compare(distances[a], distances[b]) })
+			for i := 1; i < len(order); i++ {
+				if weights[order[i]] < weights[order[i-1]] {
+					t.Fatalf("taper %d: weights are not increasing with distance from the edge", taper)
+				}
+			}
+		}
+	}
+}
+
+func TestApodizePanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	mask := make([]bool, hp.Pixels())
+	testCases := []struct {
+		name   string
+		radius float64
+		taper  Taper
+	}{
+		{"zero radius", 0, C1Taper},
+		{"invalid taper", 0.1, Taper(7)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			Apodize(hp, mask, NestScheme, tc.radius, tc.taper)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package healpix
+
+import (
+	"runtime"
+	"sync"
+)
+
+// The smallest number of elements a single goroutine is given when a batch conversion is split across
+// multiple goroutines. Below this size the cost of scheduling outweighs the work done per goroutine.
+const minBatchChunk = 1 << 14
+
+// Configures how the batch conversion functions split their work. The zero value runs every conversion
+// on the calling goroutine. The package level batch functions (AngToPix, PixToAng, etc.) use the zero value.
+type Batch struct {
+	// The maximum number of goroutines to split a conversion across. Values <= 1 run the conversion on the
+	// calling goroutine. Use runtime.GOMAXPROCS(0) to split across all available processors.
+	Workers int
+}
+
+// A Batch which splits large conversions across all available processors.
+func ParallelBatch() Batch {
+	return Batch{runtime.GOMAXPROCS(0)}
+}
+
+// Convert each latitude/longitude pair (in radians) to the index of the pixel containing it in the given
+// numbering scheme. The results are written into out, which must be at least as long as lats and lons.
+// Does not allocate. Panics if the slice lengths do not agree.
+func AngToPix(hp Healpix, scheme HealpixScheme, lats, lons []float64, out []uint) {
+	Batch{}.AngToPix(hp, scheme, lats, lons, out)
+}
+
+// Convert each pixel index in the given numbering scheme to the latitude/longitude (in radians) of the
+// center of the pixel. The results are written into lats and lons, which must be at least as long as
+// pixels. Does not allocate. Panics if the slice lengths do not agree.
+func PixToAng(hp Healpix, scheme HealpixScheme, pixels []uint, lats, lons []float64) {
+	Batch{}.PixToAng(hp, scheme, pixels, lats, lons)
+}
+
+// Convert each cartesian vector to the index of the pixel containing its direction in the given numbering
+// scheme. The vectors need not be normalized, but must not be zero. The results are written into out, which
+// must be at least as long as xs, ys and zs. Does not allocate. Panics if the slice lengths do not agree.
+func VecToPix(hp Healpix, scheme HealpixScheme, xs, ys, zs []float64, out []uint) {
+	Batch{}.VecToPix(hp, scheme, xs, ys, zs, out)
+}
+
+// Convert each nest scheme pixel index to the equivalent ring scheme pixel index. The results are written
+// into out, which must be at least as long as nest. Does not allocate. Panics if the slice lengths do not agree.
+func Nest2RingSlice(hp Healpix, nest []uint, out []uint) {
+	Batch{}.Nest2RingSlice(hp, nest, out)
+}
+
+// Convert each ring scheme pixel index to the equivalent nest scheme pixel index. The results are written
+// into out, which must be at least as long as ring. Does not allocate. Panics if the slice lengths do not agree.
+func Ring2NestSlice(hp Healpix, ring []uint, out []uint) {
+	Batch{}.Ring2NestSlice(hp, ring, out)
+}
+
+// See the package level AngToPix function.
+func (b Batch) AngToPix(hp Healpix, scheme HealpixScheme, lats, lons []float64, out []uint) {
+	if len(lats) != len(lons) || len(out) < len(lats) {
+		panic("healpix: mismatched slice lengths in AngToPix")
+	}
+	if workers := b.workers(len(lats)); workers > 1 {
+		split(len(lats), workers, func(lo, hi int) {
+			angToPix(hp, scheme, lats[lo:hi], lons[lo:hi], out[lo:hi])
+		})
+		return
+	}
+	angToPix(hp, scheme, lats, lons, out)
+}
+
+// See the package level PixToAng function.
+func (b Batch) PixToAng(hp Healpix, scheme HealpixScheme, pixels []uint, lats, lons []float64) {
+	if len(lats) < len(pixels) || len(lons) < len(pixels) {
+		panic("healpix: mismatched slice lengths in PixToAng")
+	}
+	if workers := b.workers(len(pixels)); workers > 1 {
+		split(len(pixels), workers, func(lo, hi int) {
+			pixToAng(hp, scheme, pixels[lo:hi], lats[lo:hi], lons[lo:hi])
+		})
+		return
+	}
+	pixToAng(hp, scheme, pixels, lats, lons)
+}
+
+// See the package level VecToPix function.
+func (b Batch) VecToPix(hp Healpix, scheme HealpixScheme, xs, ys, zs []float64, out []uint) {
+	if len(xs) != len(ys) || len(xs) != len(zs) || len(out) < len(xs) {
+		panic("healpix: mismatched slice lengths in VecToPix")
+	}
+	if workers := b.workers(len(xs)); workers > 1 {
+		split(len(xs), workers, func(lo, hi int) {
+			vecToPix(hp, scheme, xs[lo:hi], ys[lo:hi], zs[lo:hi], out[lo:hi])
+		})
+		return
+	}
+	vecToPix(hp, scheme, xs, ys, zs, out)
+}
+
+// See the package level Nest2RingSlice function.
+func (b Batch) Nest2RingSlice(hp Healpix, nest []uint, out []uint) {
+	if len(out) < len(nest) {
+		panic("healpix: mismatched slice lengths in Nest2RingSlice")
+	}
+	if workers := b.workers(len(nest)); workers > 1 {
+		split(len(nest), workers, func(lo, hi int) {
+			nest2Ring(hp, nest[lo:hi], out[lo:hi])
+		})
+		return
+	}
+	nest2Ring(hp, nest, out)
+}
+
+// See the package level Ring2NestSlice function.
+func (b Batch) Ring2NestSlice(hp Healpix, ring []uint, out []uint) {
+	if len(out) < len(ring) {
+		panic("healpix: mismatched slice lengths in Ring2NestSlice")
+	}
+	if workers := b.workers(len(ring)); workers > 1 {
+		split(len(ring), workers, func(lo, hi int) {
+			ring2Nest(hp, ring[lo:hi], out[lo:hi])
+		})
+		return
+	}
+	ring2Nest(hp, ring, out)
+}
+
+func angToPix(hp Healpix, scheme HealpixScheme, lats, lons []float64, out []uint) {
+	for i := range lats {
+		out[i] = NewLatLonCoordinate(lats[i], lons[i]).PixelId(hp, scheme)
+	}
+}
+
+func pixToAng(hp Healpix, scheme HealpixScheme, pixels []uint, lats, lons []float64) {
+	for i, p := range pixels {
+		var pos SphereCoordinate
+		if scheme == NestScheme {
+			pos = NestPixel(p).ToSphereCoordinate(hp)
+		} else {
+			pos = RingPixel(p).ToSphereCoordinate(hp)
+		}
+		lats[i] = pos.Latitude()
+		lons[i] = pos.Longitude()
+	}
+}
+
+func vecToPix(hp Healpix, scheme HealpixScheme, xs, ys, zs []float64, out []uint) {
+	for i := range xs {
+		out[i] = Vector{xs[i], ys[i], zs[i]}.PixelId(hp, scheme)
+	}
+}
+
+func nest2Ring(hp Healpix, nest []uint, out []uint) {
+	for i, p := range nest {
+		out[i] = uint(NestPixel(p).ToRingPixel(hp))
+	}
+}
+
+func ring2Nest(hp Healpix, ring []uint, out []uint) {
+	for i, p := range ring {
+		out[i] = uint(RingPixel(p).ToNestPixel(hp))
+	}
+}
+
+// The number of goroutines a conversion over n elements should be split across.
+func (b Batch) workers(n int) int {
+	return min(b.Workers, n/minBatchChunk)
+}
+
+// Run fn over the range [0, n), splitting the range into contiguous chunks across the given number of
+// goroutines. Each chunk is written by exactly one goroutine, so results are identical regardless of the
+// number of workers.
+func split(n int, workers int, fn func(lo, hi int)) {
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,101 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBatchMatchesWhere(t *testing.T) {
+	hp := New(NewHealpixOrder(6))
+	rng := rand.New(rand.NewSource(26))
+
+	n := 3 * minBatchChunk
+	lats := make([]float64, n)
+	lons := make([]float64, n)
+	xs := make([]float64, n)
+	ys := make([]float64, n)
+	zs := make([]float64, n)
+	for i := 0; i < n; i++ {
+		lats[i] = math.Asin(2*rng.Float64() - 1)
+		lons[i] = 2 * math.Pi * rng.Float64()
+		xs[i] = math.Cos(lats[i]) * math.Cos(lons[i])
+		ys[i] = math.Cos(lats[i]) * math.Sin(lons[i])
+		zs[i] = math.Sin(lats[i])
+	}
+
+	for _, batch := range []Batch{{}, {Workers: 4}} {
+		for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+			angPix := make([]uint, n)
+			vecPix := make([]uint, n)
+			batch.AngToPix(hp, scheme, lats, lons, angPix)
+			batch.VecToPix(hp, scheme, xs, ys, zs, vecPix)
+			for i := 0; i < n; i++ {
+				expected := NewLatLonCoordinate(lats[i], lons[i]).PixelId(hp, scheme)
+				if angPix[i] != expected {
+					t.Fatalf("workers %d scheme %v: AngToPix at %d expected %v, got %v instead", batch.Workers, scheme, i, expected, angPix[i])
+				}
+				if vecPix[i] != expected {
+					t.Fatalf("workers %d scheme %v: VecToPix at %d expected %v, got %v instead", batch.Workers, scheme, i, expected, vecPix[i])
+				}
+			}
+
+			cLats := make([]float64, n)
+			cLons := make([]float64, n)
+			batch.PixToAng(hp, scheme, angPix, cLats, cLons)
+			for i := 0; i < n; i++ {
+				var expected SphereCoordinate
+				if scheme == NestScheme {
+					expected = NestPixel(angPix[i]).ToSphereCoordinate(hp)
+				} else {
+					expected = RingPixel(angPix[i]).ToSphereCoordinate(hp)
+				}
+				if cLats[i] != expected.Latitude() || cLons[i] != expected.Longitude() {
+					t.Fatalf("workers %d scheme %v: PixToAng at %d expected %v, got %v,%v instead", batch.Workers, scheme, i, expected, cLats[i], cLons[i])
+				}
+			}
+		}
+	}
+}
+
+func TestBatchNestRingRoundTrip(t *testing.T) {
+	hp := New(NewHealpixOrder(5))
+	nest := make([]uint, hp.Pixels())
+	for i := range nest {
+		nest[i] = uint(i)
+	}
+	ring := make([]uint, len(nest))
+	back := make([]uint, len(nest))
+	Nest2RingSlice(hp, nest, ring)
+	Ring2NestSlice(hp, ring, back)
+	for i := range nest {
+		if ring[i] != uint(NestPixel(i).ToRingPixel(hp)) {
+			t.Fatalf("Nest %d expected ring %v, got %v instead", i, NestPixel(i).ToRingPixel(hp), ring[i])
+		}
+		if back[i] != nest[i] {
+			t.Fatalf("Nest %d did not survive ring round trip, got %v instead", i, back[i])
+		}
+	}
+}
+
+func TestBatchDoesNotAllocate(t *testing.T) {
+	hp := New(NewHealpixOrder(8))
+	lats := []float64{0.1, -0.5, 1.2, -1.4}
+	lons := []float64{0.3, 2.5, 4.1, 6.0}
+	out := make([]uint, len(lats))
+	allocs := testing.AllocsPerRun(100, func() {
+		AngToPix(hp, NestScheme, lats, lons, out)
+	})
+	if allocs != 0 {
+		t.Errorf("AngToPix expected no allocations, got %v instead", allocs)
+	}
+}
+
+func TestBatchMismatchedLengthsPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected mismatched slice lengths to panic")
+		}
+	}()
+	AngToPix(New(NewHealpixOrder(0)), NestScheme, []float64{0, 1}, []float64{0}, make([]uint, 2))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/owlpinetech/healpix"
+)
+
+type pixelRecord struct {
+	Latitude  float64  `json:"lat"`
+	Longitude float64  `json:"lon"`
+	Pixel     uint     `json:"pixel"`
+	Fields    []string `json:"fields,omitempty"` // the further fields of the record
+}
+
+type schemeRecord struct {
+	Nest   uint     `json:"nest"`
+	Ring   uint     `json:"ring"`
+	Fields []string `json:"fields,omitempty"` // the further fields of the record
+}
+
+// Read latitude,longitude records and append the pixel containing each position. Any further fields of a
+// record are passed through unchanged, or as a "fields" array of strings in JSON output.
+func runAng2Pix(args []string, s streams) error {
+	fs, o := newFlagSet("ang2pix", s)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+	scheme, err := o.healpixScheme()
+	if err != nil {
+		return err
+	}
+	out := newOutput(o)
+	err = newRecords(o, fs.Args()).each(func(fields []string) error {
+		if len(fields) < 2 {
+			return fmt.Errorf("expected latitude and longitude, got %d fields", len(fields))
+		}
+		lat, err := o.parseAngle(fields[0])
+		if err != nil {
+			return err
+		}
+		lon, err := o.parseAngle(fields[1])
+		if err != nil {
+			return err
+		}
+		id := healpix.NewLatLonCoordinate(lat, lon).PixelId(hp, scheme)
+		// echo the angles exactly as given, rather than after a round trip through radians
+		record := pixelRecord{number(fields[0]), number(fields[1]), id, fields[2:]}
+		return out.write(append(fields, strconv.FormatUint(uint64(id), 10)), record)
+	})
+	if err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// Read pixel records and append the latitude and longitude of the center of each pixel. Any further fields
+// of a record are passed through unchanged, or as a "fields" array of strings in JSON output.
+func runPix2Ang(args []string, s streams) error {
+	fs, o := newFlagSet("pix2ang", s)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+	scheme, err := o.healpixScheme()
+	if err != nil {
+		return err
+	}
+	out := newOutput(o)
+	err = newRecords(o, fs.Args()).each(func(fields []string) error {
+		id, err := parsePixel(hp, fields[0])
+		if err != nil {
+			return err
+		}
+		center := pixel(id, scheme).ToSphereCoordinate(hp)
+		record := pixelRecord{o.angle(center.Latitude()), o.angle(center.Longitude()), id, fields[1:]}
+		return out.write(append(fields, o.formatAngle(center.Latitude()), o.formatAngle(center.Longitude())), record)
+	})
+	if err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+func runNest2Ring(args []string, s streams) error {
+	return runConvertScheme("nest2ring", healpix.NestScheme, args, s)
+}
+
+func runRing2Nest(args []string, s streams) error {
+	return runConvertScheme("ring2nest", healpix.RingScheme, args, s)
+}
+
+// Read pixel records in one scheme and append the index of each pixel in the other scheme. Any further
+// fields of a record are passed through unchanged, or as a "fields" array of strings in JSON output.
+func runConvertScheme(name string, from healpix.HealpixScheme, args []string, s streams) error {
+	fs, o := newFlagSet(name, s)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+	to := healpix.RingScheme
+	if from == healpix.RingScheme {
+		to = healpix.NestScheme
+	}
+	out := newOutput(o)
+	err = newRecords(o, fs.Args()).each(func(fields []string) error {
+		id, err := parsePixel(hp, fields[0])
+		if err != nil {
+			return err
+		}
+		converted := pixel(id, from).PixelId(hp, to)
+		record := schemeRecord{id, converted, fields[1:]}
+		if from == healpix.RingScheme {
+			record = schemeRecord{converted, id, fields[1:]}
+		}
+		return out.write(append(fields, strconv.FormatUint(uint64(converted), 10)), record)
+	})
+	if err != nil {
+		return err
+	}
+	return out.flush()
+}
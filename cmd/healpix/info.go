@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+)
+
+type infoRecord struct {
+	Order              int     `json:"order"`
+	NSide              int     `json:"nside"`
+	FacePixels         int     `json:"face_pixels"`
+	Pixels             uint    `json:"pixels"`
+	PolarRegionPixels  int     `json:"polar_region_pixels"`
+	Rings              int     `json:"rings"`
+	AngularResolution  float64 `json:"angular_resolution"`
+	MaxPixelRadius     float64 `json:"max_pixel_radius"`
+	PixelArea          float64 `json:"pixel_area"`
+	PixelSurfaceArea   float64 `json:"pixel_surface_area"`
+	AngularResolutionM float64 `json:"angular_resolution_m"`
+}
+
+// Print a summary of the map, as cmd/summary does. Angles are in the units selected by the flags, and
+// distances and areas are on a sphere of the given radius.
+func runInfo(args []string, s streams) error {
+	fs, o := newFlagSet("info", s)
+	radius := fs.Float64("radius", 6371000, "Radius in meters of the sphere used for distances and areas")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+
+	record := infoRecord{
+		Order:              hp.Order(),
+		NSide:              hp.FaceSidePixels(),
+		FacePixels:         hp.FacePixels(),
+		Pixels:             hp.Pixels(),
+		PolarRegionPixels:  hp.PolarRegionPixels(),
+		Rings:              hp.Rings(),
+		AngularResolution:  o.angle(hp.AngularResolution()),
+		MaxPixelRadius:     o.angle(hp.MaxPixelRadius()),
+		PixelArea:          hp.PixelArea(),
+		PixelSurfaceArea:   hp.PixelSurfaceArea(*radius),
+		AngularResolutionM: hp.AngularResolution() * *radius,
+	}
+	if *o.json {
+		return newOutput(o).write(nil, record)
+	}
+
+	unit := "degrees"
+	if *o.radians {
+		unit = "radians"
+	}
+	w := tabwriter.NewWriter(o.stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Order:\t%d\n", record.Order)
+	fmt.Fprintf(w, "NSide:\t%d\n", record.NSide)
+	fmt.Fprintf(w, "Face Pixels:\t%d\n", record.FacePixels)
+	fmt.Fprintf(w, "Total Pixels:\t%d\n", record.Pixels)
+	fmt.Fprintf(w, "Polar Region Pixels:\t%d\n", record.PolarRegionPixels)
+	fmt.Fprintf(w, "Rings:\t%d\n", record.Rings)
+	fmt.Fprintf(w, "Angular Resolution:\t%.12g %s\n", record.AngularResolution, unit)
+	fmt.Fprintf(w, "Angular Resolution (Sphere):\t%.6f m\n", record.AngularResolutionM)
+	fmt.Fprintf(w, "Max Pixel Radius:\t%.12g %s\n", record.MaxPixelRadius, unit)
+	fmt.Fprintf(w, "Pixel Area:\t%.12g steradians\n", record.PixelArea)
+	fmt.Fprintf(w, "Pixel Surface Area (Sphere):\t%.6f m^2\n", record.PixelSurfaceArea)
+	return w.Flush()
+}
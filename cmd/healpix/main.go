@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/owlpinetech/healpix"
+)
+
+// A subcommand of the tool, run with the arguments following its name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, s streams) error
+}
+
+// The input and output streams of the tool, which are those of the process outside of tests.
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands = []command{
+	{"ang2pix", "convert latitude,longitude records to pixels", runAng2Pix},
+	{"pix2ang", "convert pixel records to the latitude,longitude of their centers", runPix2Ang},
+	{"nest2ring", "convert nest scheme pixel records to the ring scheme", runNest2Ring},
+	{"ring2nest", "convert ring scheme pixel records to the nest scheme", runRing2Nest},
+	{"neighbors", "list the neighbors of pixel records", runNeighbors},
+	{"query", "list the pixels of a disc, polygon or strip", runQuery},
+	{"info", "summarize a HEALPix map", runInfo},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], streams{os.Stdin, os.Stdout, os.Stderr}))
+}
+
+// Run the subcommand named by the first argument, returning the exit code of the tool: 0 on success, 1 if the
+// subcommand failed and 2 if no known subcommand was given.
+func run(args []string, s streams) int {
+	if len(args) < 1 {
+		usage(s.stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:], s); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(s.stderr, "healpix %s: %v\n", cmd.name, err)
+			}
+			return 1
+		}
+		return 0
+	}
+	usage(s.stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: healpix <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t%-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRecords are read from the arguments following the flags, one per argument, or else")
+	fmt.Fprintln(w, "from stdin, one per line. Angles are latitude and longitude in degrees unless -radians")
+	fmt.Fprintln(w, "is given. Run 'healpix <command> -h' for the flags of a command.")
+}
+
+// The flags shared by every subcommand, describing the map, how positions are written and the format of
+// the records read and written.
+type options struct {
+	streams
+	order   *int
+	nside   *int
+	scheme  *string
+	radians *bool
+	json    *bool
+	tsv     *bool
+}
+
+func newFlagSet(name string, s streams) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(s.stderr)
+	return fs, &options{
+		streams: s,
+		order:   fs.Int("order", -1, "Healpix order for the map"),
+		nside:   fs.Int("nside", 0, "Healpix nside for the map, if no order is given"),
+		scheme:  fs.String("scheme", "nest", "Pixel numbering scheme, nest or ring"),
+		radians: fs.Bool("radians", false, "Read and write angles in radians rather than degrees"),
+		json:    fs.Bool("json", false, "Write one JSON object per record rather than delimited fields"),
+		tsv:     fs.Bool("tsv", false, "Read and write tab separated rather than comma separated fields"),
+	}
+}
+
+// The HEALPix map described by the order or nside flags.
+func (o *options) healpix() (healpix.Healpix, error) {
+	if *o.order >= 0 {
+		if !healpix.IsValidOrder(*o.order) {
+			return healpix.Healpix{}, fmt.Errorf("invalid order %d, must be between 0 and %d", *o.order, healpix.MaxOrder())
+		}
+		return healpix.New(healpix.NewHealpixOrder(*o.order)), nil
+	}
+	if *o.nside > 0 {
+		if !healpix.IsValidNSide(*o.nside) {
+			return healpix.Healpix{}, fmt.Errorf("invalid nside %d, must be a power of 2 between 1 and %d", *o.nside, healpix.MaxNSide())
+		}
+		return healpix.New(healpix.NewHealpixSide(*o.nside)), nil
+	}
+	return healpix.Healpix{}, errors.New("one of -order or -nside is required")
+}
+
+// The pixel numbering scheme described by the scheme flag.
+func (o *options) healpixScheme() (healpix.HealpixScheme, error) {
+	switch strings.ToLower(*o.scheme) {
+	case "nest", "nested":
+		return healpix.NestScheme, nil
+	case "ring":
+		return healpix.RingScheme, nil
+	}
+	return 0, fmt.Errorf("unknown scheme %q, must be nest or ring", *o.scheme)
+}
+
+// Parse an angle in the units selected by the flags, returning it in radians.
+func (o *options) parseAngle(field string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid angle %q", field)
+	}
+	if *o.radians {
+		return v, nil
+	}
+	return v * math.Pi / 180, nil
+}
+
+// The value of a field already validated by parseAngle.
+func number(field string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	return v
+}
+
+// An angle in radians, converted to the units selected by the flags.
+func (o *options) angle(radians float64) float64 {
+	if *o.radians {
+		return radians
+	}
+	return radians * 180 / math.Pi
+}
+
+func (o *options) formatAngle(radians float64) string {
+	return strconv.FormatFloat(o.angle(radians), 'g', -1, 64)
+}
+
+// The position of the pixel with the given index in the given scheme.
+func pixel(id uint, scheme healpix.HealpixScheme) healpix.Where {
+	if scheme == healpix.RingScheme {
+		return healpix.RingPixel(id)
+	}
+	return healpix.NestPixel(id)
+}
+
+func parsePixel(hp healpix.Healpix, field string) (uint, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+	if err != nil || uint(id) >= hp.Pixels() {
+		return 0, fmt.Errorf("invalid pixel %q, must be between 0 and %d", field, hp.Pixels()-1)
+	}
+	return uint(id), nil
+}
+
+// Reads delimited records, either from the positional arguments of a command, each of which is one record,
+// or else from the input stream.
+type records struct {
+	reader *csv.Reader
+	line   int
+}
+
+func newRecords(o *options, args []string) *records {
+	input := o.stdin
+	comma := ','
+	if *o.tsv {
+		comma = '\t'
+	}
+	if len(args) > 0 {
+		input = strings.NewReader(strings.Join(args, "\n"))
+	}
+	reader := csv.NewReader(input)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	return &records{reader, 0}
+}
+
+// Call fn with every record, stopping at the first error. Errors are reported with the line they occurred on.
+func (r *records) each(fn func(fields []string) error) error {
+	for {
+		fields, err := r.reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.line++
+		if err := fn(fields); err != nil {
+			return fmt.Errorf("record %d: %w", r.line, err)
+		}
+	}
+}
+
+// Writes records to the output stream, either as delimited fields or as one JSON object per line.
+type output struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+func newOutput(o *options) *output {
+	if *o.json {
+		return &output{json: json.NewEncoder(o.stdout)}
+	}
+	w := csv.NewWriter(o.stdout)
+	if *o.tsv {
+		w.Comma = '\t'
+	}
+	return &output{csv: w}
+}
+
+// Write a record, as the given fields or as the given JSON object depending on the output format.
+func (out *output) write(fields []string, object any) error {
+	if out.json != nil {
+		return out.json.Encode(object)
+	}
+	return out.csv.Write(fields)
+}
+
+func (out *output) flush() error {
+	if out.csv == nil {
+		return nil
+	}
+	out.csv.Flush()
+	return out.csv.Error()
+}
+
+func formatPixels(pixels []uint) []string {
+	fields := make([]string, len(pixels))
+	for i, p := range pixels {
+		fields[i] = strconv.FormatUint(uint64(p), 10)
+	}
+	return fields
+}
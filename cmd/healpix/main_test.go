@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/owlpinetech/healpix"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"ang2pix from arguments", []string{"ang2pix", "-order", "0", "41.8103148957786,45,tag", "0,0"}, "",
+			0, "41.8103148957786,45,tag,0\n0,0,4\n", ""},
+		{"ang2pix radians", []string{"ang2pix", "-order", "0", "-radians", "0,0"}, "",
+			0, "0,0,4\n", ""},
+		{"ang2pix json", []string{"ang2pix", "-order", "0", "-json", "0,0"}, "",
+			0, "{\"lat\":0,\"lon\":0,\"pixel\":4}\n", ""},
+		{"pix2ang from stdin", []string{"pix2ang", "-order", "0"}, "4\n0,x\n",
+			0, "4,0,0\n0,x,41.8103148957786,45\n", ""},
+		{"nest2ring", []string{"nest2ring", "-order", "1", "18,a"}, "",
+			0, "18,a,27\n", ""},
+		{"ang2pix json keeps further fields", []string{"ang2pix", "-order", "0", "-json", "0,0,a,b"}, "",
+			0, "{\"lat\":0,\"lon\":0,\"pixel\":4,\"fields\":[\"a\",\"b\"]}\n", ""},
+		{"pix2ang json keeps further fields", []string{"pix2ang", "-order", "0", "-json", "4,x"}, "",
+			0, "{\"lat\":0,\"lon\":0,\"pixel\":4,\"fields\":[\"x\"]}\n", ""},
+		{"nest2ring json keeps further fields", []string{"nest2ring", "-order", "1", "-json", "18,a"}, "",
+			0, "{\"nest\":18,\"ring\":27,\"fields\":[\"a\"]}\n", ""},
+		{"ring2nest json", []string{"ring2nest", "-order", "1", "-json", "27"}, "",
+			0, "{\"nest\":18,\"ring\":27}\n", ""},
+		{"nest2ring tsv", []string{"nest2ring", "-nside", "2", "-tsv"}, "18\ta\n# comment\n0\n",
+			0, "18\ta\t27\n0\t13\n", ""},
+		{"neighbors keeps further fields", []string{"neighbors", "-order", "0", "4,tag"}, "",
+			0, "4,tag,8,5,11,0,7,3\n", ""},
+		{"neighbors json", []string{"neighbors", "-order", "0", "-json", "4,tag"}, "",
+			0, "{\"pixel\":4,\"neighbors\":[8,5,11,0,7,3],\"fields\":[\"tag\"]}\n", ""},
+		{"query disc", []string{"query", "disc", "-order", "1", "-lat", "0", "-lon", "0", "-radius", "20"}, "",
+			0, "19\n16\n", ""},
+		{"query strip", []string{"query", "strip", "-order", "0", "-scheme", "ring", "-lat1", "10", "-lat2", "-10", "-json"}, "",
+			0, "{\"pixel\":4}\n{\"pixel\":5}\n{\"pixel\":6}\n{\"pixel\":7}\n", ""},
+		{"query polygon", []string{"query", "polygon", "-order", "0", "-inclusive"}, "0,-10\n0,10\n10,0\n",
+			0, "4\n", ""},
+		{"info json", []string{"info", "-order", "0", "-json", "-radians", "-radius", "1"}, "",
+			0, "{\"order\":0,\"nside\":1,\"face_pixels\":1,\"pixels\":12,\"polar_region_pixels\":0,\"rings\":3," +
+				"\"angular_resolution\":1.0233267079464885,\"max_pixel_radius\":0.8410686705679301," +
+				"\"pixel_area\":1.0471975511965976,\"pixel_surface_area\":1.0471975511965976,\"angular_resolution_m\":1.0233267079464885}\n", ""},
+
+		{"invalid pixel", []string{"nest2ring", "-order", "0", "12"}, "",
+			1, "", "healpix nest2ring: record 1: invalid pixel \"12\", must be between 0 and 11\n"},
+		{"invalid pixel from stdin", []string{"pix2ang", "-order", "1"}, "1\nx\n",
+			1, "", "healpix pix2ang: record 2: invalid pixel \"x\", must be between 0 and 47\n"},
+		{"invalid angle", []string{"ang2pix", "-order", "1", "north,0"}, "",
+			1, "", "healpix ang2pix: record 1: invalid angle \"north\"\n"},
+		{"missing longitude", []string{"ang2pix", "-order", "1", "10"}, "",
+			1, "", "healpix ang2pix: record 1: expected latitude and longitude, got 1 fields\n"},
+		{"missing order", []string{"nest2ring", "1"}, "",
+			1, "", "healpix nest2ring: one of -order or -nside is required\n"},
+		{"invalid nside", []string{"nest2ring", "-nside", "3", "1"}, "",
+			1, "", "healpix nest2ring: invalid nside 3, must be a power of 2 between 1 and 536870912\n"},
+		{"unknown scheme", []string{"pix2ang", "-order", "1", "-scheme", "spiral", "1"}, "",
+			1, "", "healpix pix2ang: unknown scheme \"spiral\", must be nest or ring\n"},
+		{"too few polygon vertices", []string{"query", "polygon", "-order", "1", "0,0", "0,10"}, "",
+			1, "", "healpix query: expected at least 3 vertices, got 2\n"},
+		{"concave polygon", []string{"query", "polygon", "-order", "1", "0,0", "10,10", "0,20", "20,10"}, "",
+			1, "", "healpix query: polygon is not convex, vertex 3 lies outside the edge from vertex 0\n"},
+		{"unknown shape", []string{"query", "cone", "-order", "1"}, "",
+			1, "", "healpix query: unknown shape \"cone\", must be disc, polygon or strip\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, streams{strings.NewReader(tc.stdin), &stdout, &stderr})
+			if code != tc.code {
+				t.Errorf("Expected exit code %v, got %v instead", tc.code, code)
+			}
+			if tc.code == 0 && stdout.String() != tc.stdout {
+				t.Errorf("Expected output %q, got %q instead", tc.stdout, stdout.String())
+			}
+			if stderr.String() != tc.stderr {
+				t.Errorf("Expected error output %q, got %q instead", tc.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", []string{}, 2},
+		{"unknown command", []string{"pix2vec"}, 2},
+		{"command help", []string{"pix2ang", "-h"}, 1},
+		{"unknown flag", []string{"pix2ang", "-bogus"}, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tc.args, streams{strings.NewReader(""), &stdout, &stderr}); code != tc.code {
+				t.Errorf("Expected exit code %v, got %v instead", tc.code, code)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("Expected no output, got %q instead", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), "Usage") && !strings.Contains(stderr.String(), "-order") {
+				t.Errorf("Expected usage on the error output, got %q instead", stderr.String())
+			}
+		})
+	}
+}
+
+func TestQueryPolygonPassesOnOtherPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic other than for a polygon that is not convex to be passed on")
+		}
+	}()
+	hp := healpix.New(healpix.NewHealpixOrder(1))
+	queryPolygon(hp, []healpix.Where{healpix.NewLatLonCoordinate(0, 0)}, healpix.NestScheme, false)
+}
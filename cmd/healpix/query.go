@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/owlpinetech/healpix"
+)
+
+type neighborsRecord struct {
+	Pixel     uint     `json:"pixel"`
+	Neighbors []uint   `json:"neighbors"`
+	Fields    []string `json:"fields,omitempty"` // the further fields of the record
+}
+
+type queryRecord struct {
+	Pixel uint `json:"pixel"`
+}
+
+// Read pixel records and append the neighbors of each pixel, in the order returned by healpix.Neighbors. Any
+// further fields of a record are passed through unchanged, or as a "fields" array of strings in JSON output.
+func runNeighbors(args []string, s streams) error {
+	fs, o := newFlagSet("neighbors", s)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+	scheme, err := o.healpixScheme()
+	if err != nil {
+		return err
+	}
+	out := newOutput(o)
+	err = newRecords(o, fs.Args()).each(func(fields []string) error {
+		id, err := parsePixel(hp, fields[0])
+		if err != nil {
+			return err
+		}
+		neighbors := healpix.Neighbors(hp, pixel(id, scheme), scheme)
+		return out.write(append(fields, formatPixels(neighbors)...), neighborsRecord{id, neighbors, fields[1:]})
+	})
+	if err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// Write one record for each pixel of a disc, polygon or strip.
+func runQuery(args []string, s streams) error {
+	if len(args) == 0 {
+		return errors.New("expected a shape: disc, polygon or strip")
+	}
+	shape := args[0]
+	fs, o := newFlagSet("query "+shape, s)
+	inclusive := fs.Bool("inclusive", false, "Include every pixel overlapping the shape, rather than only those whose centers lie inside it")
+	var lat, lon, radius, lat1, lat2 *string
+	switch shape {
+	case "disc":
+		lat = fs.String("lat", "", "Latitude of the center of the disc")
+		lon = fs.String("lon", "", "Longitude of the center of the disc")
+		radius = fs.String("radius", "", "Angular radius of the disc")
+	case "polygon":
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: healpix query polygon [flags] lat,lon lat,lon lat,lon...")
+			fmt.Fprintln(fs.Output(), "The polygon must be convex, with at least three vertices.")
+			fs.PrintDefaults()
+		}
+	case "strip":
+		lat1 = fs.String("lat1", "", "Latitude of one edge of the strip")
+		lat2 = fs.String("lat2", "", "Latitude of the other edge of the strip")
+	default:
+		return fmt.Errorf("unknown shape %q, must be disc, polygon or strip", shape)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	hp, err := o.healpix()
+	if err != nil {
+		return err
+	}
+	scheme, err := o.healpixScheme()
+	if err != nil {
+		return err
+	}
+
+	var pixels []uint
+	switch shape {
+	case "disc":
+		angles, err := parseAngles(o, *lat, *lon, *radius)
+		if err != nil {
+			return err
+		}
+		center := healpix.NewLatLonCoordinate(angles[0], angles[1])
+		pixels = healpix.QueryDisc(hp, center, angles[2], scheme, *inclusive)
+	case "polygon":
+		vertices := []healpix.Where{}
+		err := newRecords(o, fs.Args()).each(func(fields []string) error {
+			if len(fields) < 2 {
+				return fmt.Errorf("expected latitude and longitude, got %d fields", len(fields))
+			}
+			angles, err := parseAngles(o, fields[0], fields[1])
+			if err != nil {
+				return err
+			}
+			vertices = append(vertices, healpix.NewLatLonCoordinate(angles[0], angles[1]))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(vertices) < 3 {
+			return fmt.Errorf("expected at least 3 vertices, got %d", len(vertices))
+		}
+		if pixels, err = queryPolygon(hp, vertices, scheme, *inclusive); err != nil {
+			return err
+		}
+	case "strip":
+		angles, err := parseAngles(o, *lat1, *lat2)
+		if err != nil {
+			return err
+		}
+		pixels = healpix.QueryStrip(hp, healpix.NewLatLonCoordinate(angles[0], 0).Colatitude(),
+			healpix.NewLatLonCoordinate(angles[1], 0).Colatitude(), scheme, *inclusive)
+	}
+
+	out := newOutput(o)
+	for _, p := range pixels {
+		if err := out.write([]string{strconv.FormatUint(uint64(p), 10)}, queryRecord{p}); err != nil {
+			return err
+		}
+	}
+	return out.flush()
+}
+
+// The start of the message healpix.QueryPolygon panics with for a polygon that is not convex.
+const nonConvexPolygon = "healpix: polygon is not convex"
+
+// Query the pixels of the polygon, reporting a polygon that is not convex as an error rather than a panic.
+// Any other panic is a bug, and is passed on.
+func queryPolygon(hp healpix.Healpix, vertices []healpix.Where, scheme healpix.HealpixScheme, inclusive bool) (pixels []uint, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			message, ok := r.(string)
+			if !ok || !strings.HasPrefix(message, nonConvexPolygon) {
+				panic(r)
+			}
+			err = errors.New(strings.TrimPrefix(message, "healpix: "))
+		}
+	}()
+	return healpix.QueryPolygon(hp, vertices, scheme, inclusive), nil
+}
+
+// Parse each of the fields as an angle, returning them in radians.
+func parseAngles(o *options, fields ...string) ([]float64, error) {
+	angles := make([]float64, len(fields))
+	for i, field := range fields {
+		if field == "" {
+			return nil, errors.New("missing required angle flag")
+		}
+		angle, err := o.parseAngle(field)
+		if err != nil {
+			return nil, err
+		}
+		angles[i] = angle
+	}
+	return angles, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+
+	"github.com/owlpinetech/healpix"
+)
+
+// An ellipsoid of revolution that a HEALPix map is laid over, treating the latitudes of the map as geodetic
+// latitudes on the body. A flattening of 0 describes a sphere.
+type body struct {
+	radius       float64 // equatorial radius, in meters
+	eccentricity float64
+}
+
+func newBody(radius float64, flattening float64) body {
+	return body{radius, math.Sqrt(flattening * (2 - flattening))}
+}
+
+// The radius of the sphere with the same surface area as the body, which relates the equal pixel areas of
+// the map on the unit sphere to areas on the body.
+func (b body) authalicRadius() float64 {
+	e := b.eccentricity
+	if e == 0 {
+		return b.radius
+	}
+	q := 1 + (1-e*e)/(2*e)*math.Log((1+e)/(1-e))
+	return b.radius * math.Sqrt(q/2)
+}
+
+// The position of a point of the map on the surface of the body, in meters from its center.
+func (b body) surface(p healpix.SphereCoordinate) [3]float64 {
+	e2 := b.eccentricity * b.eccentricity
+	sinLat := math.Sin(p.Latitude())
+	n := b.radius / math.Sqrt(1-e2*sinLat*sinLat)
+	cosLat := math.Cos(p.Latitude())
+	return [3]float64{
+		n * cosLat * math.Cos(p.Longitude()),
+		n * cosLat * math.Sin(p.Longitude()),
+		n * (1 - e2) * sinLat,
+	}
+}
+
+// The distance in meters along the surface of the body between two points of the map. Exact on a sphere;
+// on an ellipsoid the chord between the points is bent using the mean radius of curvature at their mean
+// latitude, which is accurate for points as close together as the vertices of all but the coarsest maps.
+func (b body) distance(p healpix.SphereCoordinate, q healpix.SphereCoordinate) float64 {
+	if b.eccentricity == 0 {
+		return b.radius * p.AngularDistance(q)
+	}
+	u := b.surface(p)
+	v := b.surface(q)
+	chord := math.Sqrt((u[0]-v[0])*(u[0]-v[0]) + (u[1]-v[1])*(u[1]-v[1]) + (u[2]-v[2])*(u[2]-v[2]))
+	e2 := b.eccentricity * b.eccentricity
+	sinLat := math.Sin((p.Latitude() + q.Latitude()) / 2)
+	w := 1 - e2*sinLat*sinLat
+	curvature := b.radius * math.Sqrt(1-e2) / w
+	return 2 * curvature * math.Asin(min(1, chord/(2*curvature)))
+}
@@ -4,15 +4,44 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"os"
+	"text/tabwriter"
 
 	"github.com/owlpinetech/healpix"
 )
 
+// Measuring the pixel shapes of a map means visiting every pixel of its polar caps, so beyond this order the
+// shapes are instead scaled down from the shapes at this order, which they match closely.
+const exactShapeOrder = 10
+
 func main() {
 	order := flag.Int("order", -1, "Healpix order for the map")
 	nside := flag.Int("nside", 0, "Healpix nside for the map")
+	resolution := flag.Float64("resolution", 0, "Use the coarsest order whose pixels are no wider than this many meters")
+	area := flag.Float64("area", 0, "Use the coarsest order whose pixels are no larger than this many square meters")
+	table := flag.Bool("table", false, "Print the true pixel extents of every order")
+	radius := flag.Float64("radius", 6371000, "Equatorial radius in meters of the body the map is laid over")
+	flattening := flag.Float64("flattening", 0, "Flattening of the body the map is laid over, 0 for a sphere (WGS84 is 0.0033528106647474805)")
 	flag.Parse()
 
+	if *radius <= 0 || *flattening < 0 || *flattening >= 1 {
+		fmt.Println("Invalid body. Radius must be positive and flattening between 0 and 1.")
+		return
+	}
+	b := newBody(*radius, *flattening)
+
+	if *table {
+		printTable(b)
+		return
+	}
+	if *resolution > 0 {
+		*order = healpix.OrderForResolution(*resolution / b.authalicRadius())
+		fmt.Printf("Coarsest order with pixels no wider than %g m: %d\n", *resolution, *order)
+	} else if *area > 0 {
+		*order = healpix.OrderForArea(*area / (b.authalicRadius() * b.authalicRadius()))
+		fmt.Printf("Coarsest order with pixels no larger than %g m^2: %d\n", *area, *order)
+	}
+
 	if *order < 0 && *nside <= 0 {
 		fmt.Println("HEALPix Library Limits:")
 		fmt.Printf("\tMax Order: %d\n", healpix.MaxOrder())
@@ -53,4 +82,55 @@ func main() {
 	fmt.Printf("\tPixel Area: %.18f steradians\n", hp.PixelArea())
 	fmt.Printf("\tPixel Surface Area (Earth): %.6f m^2\n", hp.PixelSurfaceArea(6371000))
 	fmt.Printf("\tTotal Data Size (uint32): ~%d MB\n", hp.Pixels()/1e6*4)
+
+	measured, shape := pixelShape(hp, b)
+	minEdge, maxEdge := scaleEdges(hp, measured, shape)
+	approx := ""
+	if measured != hp {
+		approx = "~"
+	}
+	fmt.Printf("\tMax Pixel Radius: %.18f radians\n", hp.MaxPixelRadius())
+	fmt.Printf("\tPixel Edge (Body): %s%.6f - %s%.6f m\n", approx, minEdge, approx, maxEdge)
+	fmt.Printf("\tMax Pixel Aspect Ratio: %s%.6f\n", approx, shape.MaxAspect())
+}
+
+// Print the size and shape of the pixels of every supported order as laid over the body.
+func printTable(b body) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Order\tNSide\tPixels\tMean Width (m)\tMin Edge (m)\tMax Edge (m)\tMax Aspect\tMax Radius (m)\t")
+	var measured healpix.Healpix
+	var shape healpix.PixelShape
+	for order := 0; order <= healpix.MaxOrder(); order++ {
+		hp := healpix.New(healpix.NewHealpixOrder(order))
+		approx := "~"
+		if order <= exactShapeOrder {
+			measured, shape = hp, hp.PixelShapeWith(b.distance)
+			approx = ""
+		}
+		minEdge, maxEdge := scaleEdges(hp, measured, shape)
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.6g\t%s%.6g\t%s%.6g\t%s%.4f\t%.6g\t\n",
+			order, hp.FaceSidePixels(), hp.Pixels(),
+			hp.AngularResolution()*b.authalicRadius(),
+			approx, minEdge, approx, maxEdge, approx, shape.MaxAspect(),
+			hp.MaxPixelRadius()*b.authalicRadius())
+	}
+	w.Flush()
+	fmt.Printf("Orders above %d (marked ~) are scaled from order %d.\n", exactShapeOrder, exactShapeOrder)
+}
+
+// The shape of the pixels of the map laid over the body, measured at the map's own order if it is not
+// too fine, or else at the finest order measured exactly.
+func pixelShape(hp healpix.Healpix, b body) (healpix.Healpix, healpix.PixelShape) {
+	measured := hp
+	if hp.Order() > exactShapeOrder {
+		measured = healpix.New(healpix.NewHealpixOrder(exactShapeOrder))
+	}
+	return measured, measured.PixelShapeWith(b.distance)
+}
+
+// The shortest and longest pixel edges of the map, scaled from the shape measured on a coarser map.
+// Pixel edges halve in length with each order, while the aspect ratios barely change.
+func scaleEdges(hp healpix.Healpix, measured healpix.Healpix, shape healpix.PixelShape) (float64, float64) {
+	scale := float64(measured.FaceSidePixels()) / float64(hp.FaceSidePixels())
+	return shape.MinEdge() * scale, shape.MaxEdge() * scale
 }
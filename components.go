@@ -0,0 +1,136 @@
+package healpix
+
+import "fmt"
+
+// Which neighbors of a pixel count as connected to it when traversing regions of a map. The values are the
+// number of neighbors each pixel has, so plain 4 and 8 may be used too.
+type Connectivity int
+
+const (
+	// Pixels are connected to the four neighbors they share an edge with.
+	EdgeConnectivity Connectivity = 4
+	// Pixels are connected to all eight neighbors they share an edge or a vertex with (seven at the
+	// vertices where only three faces meet).
+	VertexConnectivity Connectivity = 8
+)
+
+// Visit each neighbor of the pixel that is connected to it, stepping across face edges and around the poles
+// as Neighbor does.
+func (c Connectivity) neighbors(hp Healpix, fp FacePixel, visit func(FacePixel)) {
+	step := Direction(1)
+	first := South
+	switch c {
+	case EdgeConnectivity:
+		// the directions across the pixel's edges are the odd ones, SouthEast through SouthWest
+		step = 2
+		first = SouthEast
+	case VertexConnectivity:
+	default:
+		panic(fmt.Sprintf("healpix: invalid connectivity %d, expected 4 or 8", c))
+	}
+	for d := first; d <= SouthWest; d += step {
+		if neigh, ok := neighborFacePixel(hp, fp, d); ok {
+			visit(neigh)
+		}
+	}
+}
+
+// A set of connected pixels found by LabelComponents.
+type Component struct {
+	label    int
+	size     int
+	area     float64
+	centroid SphereCoordinate
+}
+
+// The label of the component, which is also its position in the components returned by LabelComponents.
+func (c Component) Label() int {
+	return c.label
+}
+
+// The number of pixels in the component.
+func (c Component) Size() int {
+	return c.size
+}
+
+// The area of the component in steradians.
+func (c Component) Area() float64 {
+	return c.area
+}
+
+// The centroid of the component, which is the direction of the mean of its pixel center vectors. It is
+// meaningless for a component whose pixels balance out around the center of the sphere, like a complete
+// band around the equator.
+func (c Component) Centroid() SphereCoordinate {
+	return c.centroid
+}
+
+// Find the connected regions of the pixels set in the mask, which is indexed in the given scheme. Regions
+// are followed across face edges and around the poles, so a region that straddles a face boundary, a pole
+// or the antimeridian is a single component. Returns the label of each pixel, which is -1 for the pixels
+// not in the mask, and the components, which are labeled from 0 in the order of their lowest pixel index.
+// Panics if the mask does not have one value per pixel, or the connectivity is invalid.
+func LabelComponents(hp Healpix, mask []bool, scheme HealpixScheme, connectivity Connectivity) ([]int, []Component) {
+	checkMaskSize(hp, mask)
+	labels := make([]int, len(mask))
+	for p := range labels {
+		labels[p] = -1
+	}
+	components := []Component{}
+	stack := []uint{}
+	for start := range mask {
+		if !mask[start] || labels[start] >= 0 {
+			continue
+		}
+		label := len(components)
+		labels[start] = label
+		stack = append(stack[:0], uint(start))
+		size := 0
+		sum := Vector{}
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			fp := schemePixel(hp, p, scheme)
+			size++
+			sum = sum.add(fp.ToSphereCoordinate(hp).ToVector())
+			connectivity.neighbors(hp, fp, func(neigh FacePixel) {
+				id := neigh.PixelId(hp, scheme)
+				if mask[id] && labels[id] < 0 {
+					labels[id] = label
+					stack = append(stack, id)
+				}
+			})
+		}
+		components = append(components, Component{label, size, float64(size) * hp.PixelArea(), sum.sphere()})
+	}
+	return labels, components
+}
+
+// Return the connected region of pixels that contains the seed position and satisfies the predicate, which
+// is called with pixel indices in the given scheme. Regions are followed across face edges and around the
+// poles. The pixels are returned in breadth first order outward from the seed pixel, and the result is
+// empty if the seed pixel does not satisfy the predicate. The predicate is called at most once per pixel.
+// Panics if the connectivity is invalid.
+func FloodFill(hp Healpix, seed Where, scheme HealpixScheme, connectivity Connectivity, predicate func(pixel uint) bool) []uint {
+	start := seed.ToFacePixel(hp)
+	id := start.PixelId(hp, scheme)
+	result := []uint{}
+	if !predicate(id) {
+		return result
+	}
+	seen := map[uint]struct{}{id: {}}
+	result = append(result, id)
+	for next := 0; next < len(result); next++ {
+		connectivity.neighbors(hp, schemePixel(hp, result[next], scheme), func(neigh FacePixel) {
+			id := neigh.PixelId(hp, scheme)
+			if _, found := seen[id]; found {
+				return
+			}
+			seen[id] = struct{}{}
+			if predicate(id) {
+				result = append(result, id)
+			}
+		})
+	}
+	return result
+}
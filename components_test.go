@@ -0,0 +1,187 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func discMask(hp Healpix, center Where, radius float64, scheme HealpixScheme) []bool {
+	mask := make([]bool, hp.Pixels())
+	for _, p := range QueryDisc(hp, center, radius, scheme, false) {
+		mask[p] = true
+	}
+	return mask
+}
+
+func TestLabelComponentsDiscs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		center   SphereCoordinate
+		scheme   HealpixScheme
+		latitude float64
+	}{
+		{"north pole", NewLatLonCoordinate(math.Pi/2, 0), NestScheme, math.Pi / 2},
+		{"south pole", NewLatLonCoordinate(-math.Pi/2, 0), RingScheme, -math.Pi / 2},
+		{"antimeridian", NewLatLonCoordinate(0.3, 0), RingScheme, 0.3},
+		{"face corner", NewLatLonCoordinate(math.Asin(2.0/3), math.Pi/4), NestScheme, math.Asin(2.0 / 3)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(4))
+			mask := discMask(hp, tc.center, 0.3, tc.scheme)
+			labels, components := LabelComponents(hp, mask, tc.scheme, EdgeConnectivity)
+			if len(components) != 1 {
+				t.Fatalf("expected 1 component, got %d", len(components))
+			}
+			c := components[0]
+			size := 0
+			for p, set := range mask {
+				if set {
+					size++
+					if labels[p] != 0 {
+						t.Fatalf("pixel %d: expected label 0, got %d", p, labels[p])
+					}
+				} else if labels[p] != -1 {
+					t.Fatalf("pixel %d: expected label -1, got %d", p, labels[p])
+				}
+			}
+			if c.Size() != size || !withinTolerance(c.Area(), float64(size)*hp.PixelArea(), 1e-12) {
+				t.Errorf("expected size %d, got %d with area %v", size, c.Size(), c.Area())
+			}
+			if math.Abs(c.Centroid().Latitude()-tc.latitude) > 0.02 {
+				t.Errorf("expected centroid latitude %v, got %v", tc.latitude, c.Centroid().Latitude())
+			}
+		})
+	}
+}
+
+func TestLabelComponentsSeparate(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	mask := discMask(hp, NewLatLonCoordinate(0.5, 1), 0.2, NestScheme)
+	for p, set := range discMask(hp, NewLatLonCoordinate(-0.5, 4), 0.3, NestScheme) {
+		mask[p] = mask[p] || set
+	}
+	labels, components := LabelComponents(hp, mask, NestScheme, VertexConnectivity)
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+	first := slices.Index(mask, true)
+	if labels[first] != 0 {
+		t.Errorf("expected the component with the lowest pixel to be labeled 0, got %d", labels[first])
+	}
+	for i, c := range components {
+		if c.Label() != i {
+			t.Errorf("expected component %d to have label %d, got %d", i, i, c.Label())
+		}
+	}
+}
+
+func TestLabelComponentsConnectivity(t *testing.T) {
+	// a pixel and its northern neighbor only share a vertex
+	hp := New(NewHealpixOrder(2))
+	fp := NewFacePixel(4, 1, 1)
+	north, _ := neighborFacePixel(hp, fp, North)
+	mask := make([]bool, hp.Pixels())
+	mask[fp.PixelId(hp, RingScheme)] = true
+	mask[north.PixelId(hp, RingScheme)] = true
+	if _, components := LabelComponents(hp, mask, RingScheme, EdgeConnectivity); len(components) != 2 {
+		t.Errorf("expected 2 components with edge connectivity, got %d", len(components))
+	}
+	if _, components := LabelComponents(hp, mask, RingScheme, VertexConnectivity); len(components) != 1 {
+		t.Errorf("expected 1 component with vertex connectivity, got %d", len(components))
+	}
+}
+
+func TestLabelComponentsAgainstUnionFind(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		hp := New(NewHealpixOrder(3))
+		mask := make([]bool, hp.Pixels())
+		for p := range mask {
+			mask[p] = rng.Float64() < 0.45
+		}
+		parent := make([]int, len(mask))
+		for p := range parent {
+			parent[p] = p
+		}
+		var find func(int) int
+		find = func(p int) int {
+			if parent[p] != p {
+				parent[p] = find(parent[p])
+			}
+			return parent[p]
+		}
+		for p := range mask {
+			if !mask[p] {
+				continue
+			}
+			for _, n := range Neighbors(hp, schemePixel(hp, uint(p), scheme), scheme) {
+				if mask[n] {
+					parent[find(p)] = find(int(n))
+				}
+			}
+		}
+		labels, components := LabelComponents(hp, mask, scheme, VertexConnectivity)
+		sizes := make([]int, len(components))
+		for p := range mask {
+			if !mask[p] {
+				continue
+			}
+			sizes[labels[p]]++
+			for q := range p {
+				if mask[q] && (find(p) == find(q)) != (labels[p] == labels[q]) {
+					t.Fatalf("pixels %d and %d: union find and labels disagree", p, q)
+				}
+			}
+		}
+		for i, c := range components {
+			if c.Size() != sizes[i] {
+				t.Errorf("component %d: expected size %d, got %d", i, sizes[i], c.Size())
+			}
+		}
+	}
+}
+
+func TestFloodFill(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		center := NewLatLonCoordinate(-1.4, 3)
+		mask := discMask(hp, center, 0.4, scheme)
+		calls := map[uint]int{}
+		filled := FloodFill(hp, center, scheme, EdgeConnectivity, func(p uint) bool {
+			calls[p]++
+			return mask[p]
+		})
+		if filled[0] != center.PixelId(hp, scheme) {
+			t.Errorf("expected the seed pixel first, got %d", filled[0])
+		}
+		for p, n := range calls {
+			if n != 1 {
+				t.Errorf("pixel %d: predicate called %d times", p, n)
+			}
+		}
+		expected := QueryDisc(hp, center, 0.4, scheme, false)
+		slices.Sort(expected)
+		slices.Sort(filled)
+		if !slices.Equal(filled, expected) {
+			t.Errorf("expected the disc of %d pixels, got %d pixels", len(expected), len(filled))
+		}
+	}
+	if filled := FloodFill(hp, NewLatLonCoordinate(0, 0), NestScheme, VertexConnectivity, func(uint) bool { return false }); len(filled) != 0 {
+		t.Errorf("expected no pixels when the seed fails the predicate, got %d", len(filled))
+	}
+}
+
+func TestInvalidConnectivityPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid connectivity")
+		}
+	}()
+	mask := make([]bool, hp.Pixels())
+	mask[0] = true
+	LabelComponents(hp, mask, NestScheme, Connectivity(6))
+}
@@ -0,0 +1,267 @@
+package healpix
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+)
+
+// A contour line of a map at one level, found by Contours.
+type Contour struct {
+	level  float64
+	points []SphereCoordinate
+	closed bool
+}
+
+// The map value the contour follows.
+func (c Contour) Level() float64 {
+	return c.level
+}
+
+// The points of the contour in order. Values of the map above the level lie to the left of the direction of
+// travel, as seen from outside the sphere. For a closed contour the last point connects back to the first,
+// which is not repeated.
+func (c Contour) Points() []SphereCoordinate {
+	return c.points
+}
+
+// Whether the contour is a closed loop. Contours are open only where they run into pixels without a value.
+func (c Contour) Closed() bool {
+	return c.closed
+}
+
+// The points of the contour as lines of [longitude, latitude] pairs in degrees, the coordinate order of GeoJSON,
+// with every longitude between -180 and 180. The contour is cut where it crosses the antimeridian, as RFC 7946
+// recommends, with a point at longitude 180 ending one line and a point at -180 starting the next, so there is
+// one line for each piece between crossings. A closed contour repeats its first point at the end when it does
+// not cross the antimeridian; otherwise its first and last pieces join into one line.
+func (c Contour) Lines() [][][2]float64 {
+	points := c.points
+	if c.closed && len(points) > 0 {
+		points = append(slices.Clip(points), points[0])
+	}
+	lines := [][][2]float64{}
+	line := [][2]float64{}
+	for i, p := range points {
+		lon, lat := lonLatDegrees(p)
+		if i > 0 {
+			previous := line[len(line)-1]
+			if math.Abs(lon-previous[0]) > 180 {
+				if previous[0] == 180 {
+					// the previous point lies on the antimeridian and already ends the line
+					lines = append(lines, line)
+					line = [][2]float64{{-180, previous[1]}}
+				} else {
+					// the great circle between the two points meets the antimeridian, where y = 0, at this
+					// positive combination of them
+					a, b := points[i-1].ToVector(), p.ToVector()
+					_, crossing := lonLatDegrees(a.scale(math.Abs(b.y)).add(b.scale(math.Abs(a.y))).Normalize().sphere())
+					edge := math.Copysign(180, previous[0])
+					lines = append(lines, append(line, [2]float64{edge, crossing}))
+					line = [][2]float64{{-edge, crossing}}
+				}
+			}
+		}
+		line = append(line, [2]float64{lon, lat})
+	}
+	lines = append(lines, line)
+	if c.closed && len(lines) > 1 {
+		// the last piece runs on into the first
+		last := lines[len(lines)-1]
+		lines[0] = append(last, lines[0][1:]...)
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// The longitude, between -180 and 180, and latitude of the position in degrees.
+func lonLatDegrees(p SphereCoordinate) (float64, float64) {
+	lon := p.Longitude() * 180 / math.Pi
+	if lon > 180 {
+		lon -= 360
+	}
+	return lon, p.Latitude() * 180 / math.Pi
+}
+
+// Encode the contour as a GeoJSON Feature with the level as a property. The geometry is a LineString, or a
+// MultiLineString if the contour crosses the antimeridian in more than one piece (see Lines).
+func (c Contour) MarshalJSON() ([]byte, error) {
+	type geometry struct {
+		Type        string `json:"type"`
+		Coordinates any    `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string             `json:"type"`
+		Geometry   geometry           `json:"geometry"`
+		Properties map[string]float64 `json:"properties"`
+	}
+	lines := c.Lines()
+	g := geometry{"MultiLineString", lines}
+	if len(lines) == 1 {
+		g = geometry{"LineString", lines[0]}
+	}
+	return json.Marshal(feature{"Feature", g, map[string]float64{"level": c.level}})
+}
+
+// Encode the contours as a GeoJSON FeatureCollection of features as for Contour.MarshalJSON.
+func ContoursGeoJSON(contours []Contour) ([]byte, error) {
+	return json.Marshal(struct {
+		Type     string    `json:"type"`
+		Features []Contour `json:"features"`
+	}{"FeatureCollection", contours})
+}
+
+// A point where a contour crosses the line between the centers of two pixels that share an edge, identified
+// by the two pixels.
+type contourEdge struct {
+	a, b uint
+}
+
+func newContourEdge(a uint, b uint) contourEdge {
+	if a > b {
+		a, b = b, a
+	}
+	return contourEdge{a, b}
+}
+
+// A piece of a contour crossing one cell of the dual grid, from one edge crossing to another.
+type contourSegment struct {
+	from, to contourEdge
+}
+
+// The cells of the dual grid of the map: the pixels around each pixel vertex, in the same rotational order
+// for every cell. Most vertices are shared by four pixels, but the eight vertices where only three faces meet
+// are shared by three.
+func dualCells(hp Healpix, scheme HealpixScheme, visit func(cell []uint)) {
+	neighbors := [8]int64{}
+	cell := make([]uint, 0, 4)
+	for p := uint(0); p < hp.Pixels(); p++ {
+		NeighborsInto(hp, schemePixel(hp, p, scheme), scheme, &neighbors)
+		for corner := South; corner <= West; corner += 2 {
+			cell = append(cell[:0], p)
+			for _, d := range [3]Direction{(corner + 1) % 8, corner, (corner + 7) % 8} {
+				if neighbors[d] >= 0 {
+					cell = append(cell, uint(neighbors[d]))
+				}
+			}
+			// each cell is visited once, from its lowest pixel
+			if slices.Min(cell) == p {
+				visit(cell)
+			}
+		}
+	}
+}
+
+// Return the contour lines of the map, indexed in the given scheme, at each of the given levels. The result
+// holds the contours of each level at the same position as the level. Contours are traced through the dual
+// grid of the map, whose cells join the centers of the pixels around each pixel vertex, so they continue
+// across face edges and around the poles; each contour crosses the great circle arcs between neighboring
+// pixel centers at the point found by linear interpolation of the two values. Where the values around a cell
+// alternate above and below the level, the mean of the cell decides which pairs of crossings are joined.
+// Pixels whose value is NaN are left out, and contours that run into them end there. Panics if the map does
+// not have one value per pixel.
+func Contours(hp Healpix, m []float64, scheme HealpixScheme, levels []float64) [][]Contour {
+	checkMapSize(hp, m)
+	result := make([][]Contour, len(levels))
+	segments := make([][]contourSegment, len(levels))
+	dualCells(hp, scheme, func(cell []uint) {
+		mean := 0.0
+		for _, p := range cell {
+			mean += m[p]
+		}
+		if math.IsNaN(mean) {
+			return
+		}
+		mean /= float64(len(cell))
+		for i, level := range levels {
+			segments[i] = appendCellSegments(segments[i], cell, m, level, mean)
+		}
+	})
+	for i, level := range levels {
+		result[i] = traceContours(hp, m, scheme, level, segments[i])
+	}
+	return result
+}
+
+// Add the contour segments crossing the cell at the level. Each segment runs from a crossing where the values
+// rise above the level, going around the cell, to one where they fall below it, so that the values above the
+// level lie on its left.
+func appendCellSegments(segments []contourSegment, cell []uint, m []float64, level float64, mean float64) []contourSegment {
+	n := len(cell)
+	above := func(i int) bool { return m[cell[i%n]] >= level }
+	edge := func(i int) contourEdge { return newContourEdge(cell[i%n], cell[(i+1)%n]) }
+	rising := []int{}
+	falling := []int{}
+	for i := 0; i < n; i++ {
+		if above(i) != above(i+1) {
+			if above(i + 1) {
+				rising = append(rising, i)
+			} else {
+				falling = append(falling, i)
+			}
+		}
+	}
+	switch len(rising) {
+	case 1:
+		segments = append(segments, contourSegment{edge(rising[0]), edge(falling[0])})
+	case 2:
+		// a saddle: either the corners above the level are joined through the middle of the cell, and the
+		// segments cut off the corners below it, or the other way around
+		for _, r := range rising {
+			if mean >= level {
+				// cut off the corner below the level before the rising edge
+				segments = append(segments, contourSegment{edge(r), edge(r + n - 1)})
+			} else {
+				// cut off the corner above the level after the rising edge
+				segments = append(segments, contourSegment{edge(r), edge(r + 1)})
+			}
+		}
+	}
+	return segments
+}
+
+// Join the segments of one level end to end into contours.
+func traceContours(hp Healpix, m []float64, scheme HealpixScheme, level float64, segments []contourSegment) []Contour {
+	next := make(map[contourEdge]int, len(segments))
+	ends := make(map[contourEdge]struct{}, len(segments))
+	for i, s := range segments {
+		next[s.from] = i
+		ends[s.to] = struct{}{}
+	}
+	used := make([]bool, len(segments))
+	crossing := func(e contourEdge) SphereCoordinate {
+		a := schemePixel(hp, e.a, scheme).ToSphereCoordinate(hp)
+		b := schemePixel(hp, e.b, scheme).ToSphereCoordinate(hp)
+		return a.Interpolate(b, (level-m[e.a])/(m[e.b]-m[e.a]))
+	}
+	trace := func(first int) Contour {
+		points := []SphereCoordinate{crossing(segments[first].from)}
+		i := first
+		for {
+			used[i] = true
+			end := segments[i].to
+			j, found := next[end]
+			if found && j == first {
+				return Contour{level, points, true}
+			}
+			points = append(points, crossing(end))
+			if !found || used[j] {
+				return Contour{level, points, false}
+			}
+			i = j
+		}
+	}
+	contours := []Contour{}
+	// open contours start at a crossing no other segment ends at
+	for i, s := range segments {
+		if _, found := ends[s.from]; !found && !used[i] {
+			contours = append(contours, trace(i))
+		}
+	}
+	for i := range segments {
+		if !used[i] {
+			contours = append(contours, trace(i))
+		}
+	}
+	return contours
+}
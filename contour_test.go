@@ -0,0 +1,210 @@
+package healpix
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestContoursOfLatitude(t *testing.T) {
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		hp := New(NewHealpixOrder(4))
+		m := sampleMap(hp, scheme, func(v Vector) float64 { return v.z })
+		levels := []float64{-0.9, -0.2, 0, 0.5, 0.95}
+		contours := Contours(hp, m, scheme, levels)
+		for i, level := range levels {
+			if len(contours[i]) != 1 {
+				t.Fatalf("level %v: expected 1 contour, got %d", level, len(contours[i]))
+			}
+			c := contours[i][0]
+			if !c.Closed() || c.Level() != level {
+				t.Errorf("level %v: expected a closed contour at the level, got closed %v at %v", level, c.Closed(), c.Level())
+			}
+			for _, p := range c.Points() {
+				if math.Abs(p.Latitude()-math.Asin(level)) > 2e-3 {
+					t.Fatalf("level %v: expected latitude %v, got %v", level, math.Asin(level), p.Latitude())
+				}
+			}
+			// higher values lie to the north, on the left, so the contour runs eastward once around the pole,
+			// from the antimeridian back to it
+			lines := c.Lines()
+			if len(lines) != 1 {
+				t.Fatalf("level %v: expected 1 line, got %d", level, len(lines))
+			}
+			line := lines[0]
+			if line[0][0] != -180 || line[len(line)-1][0] != 180 || line[0][1] != line[len(line)-1][1] {
+				t.Errorf("level %v: expected the line to run from -180 to 180 at one latitude, got %v to %v", level, line[0], line[len(line)-1])
+			}
+			for i := 1; i < len(line); i++ {
+				if line[i][0] < line[i-1][0]-1e-9 {
+					t.Fatalf("level %v: expected the line to run eastward, got %v after %v", level, line[i], line[i-1])
+				}
+			}
+		}
+	}
+}
+
+func TestContoursAroundVertices(t *testing.T) {
+	testCases := []struct {
+		name   string
+		center SphereCoordinate
+	}{
+		{"north pole", NewLatLonCoordinate(math.Pi/2, 0)},
+		{"three faces", NewLatLonCoordinate(math.Asin(2.0/3), 0)},
+		{"four faces", NewLatLonCoordinate(0, math.Pi/4)},
+		{"south three faces", NewLatLonCoordinate(-math.Asin(2.0/3), math.Pi/2)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(3))
+			c := tc.center.ToVector()
+			m := sampleMap(hp, NestScheme, func(v Vector) float64 { return v.dot(c) })
+			radius := 0.3
+			contours := Contours(hp, m, NestScheme, []float64{math.Cos(radius)})[0]
+			if len(contours) != 1 || !contours[0].Closed() {
+				t.Fatalf("expected 1 closed contour, got %d", len(contours))
+			}
+			for _, p := range contours[0].Points() {
+				if d := angleBetween(p.ToVector(), c); math.Abs(d-radius) > 1e-2 {
+					t.Fatalf("expected contour points %v from the center, got %v", radius, d)
+				}
+			}
+		})
+	}
+}
+
+func TestContoursClosedOnSmoothField(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	m := sampleMap(hp, RingScheme, func(v Vector) float64 {
+		return v.x*v.y + 0.5*v.z*v.x - 0.3*v.y + 0.2*v.z*v.z
+	})
+	for i, contours := range Contours(hp, m, RingScheme, []float64{-0.4, -0.1, 0, 0.1, 0.3}) {
+		if len(contours) == 0 {
+			t.Errorf("level %d: expected contours", i)
+		}
+		for _, c := range contours {
+			if !c.Closed() || len(c.Points()) < 3 {
+				t.Errorf("level %d: expected closed contours, got closed %v with %d points", i, c.Closed(), len(c.Points()))
+			}
+		}
+	}
+}
+
+func TestContoursOpenAtMissingValues(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	m := sampleMap(hp, NestScheme, func(v Vector) float64 { return v.z })
+	// a missing band of longitudes cuts the circles of latitude open
+	for p, center := range hp.AllPixelCenters(NestScheme) {
+		if center.Longitude() < 0.5 {
+			m[p] = math.NaN()
+		}
+	}
+	contours := Contours(hp, m, NestScheme, []float64{0.3})[0]
+	if len(contours) != 1 || contours[0].Closed() {
+		t.Fatalf("expected 1 open contour, got %d", len(contours))
+	}
+	points := contours[0].Points()
+	if first, last := points[0].Longitude(), points[len(points)-1].Longitude(); first > last {
+		t.Errorf("expected the open contour to run eastward, from %v to %v", first, last)
+	}
+}
+
+func TestContoursGeoJSON(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	m := sampleMap(hp, NestScheme, func(v Vector) float64 { return v.z })
+	contours := Contours(hp, m, NestScheme, []float64{0.5})[0]
+	data, err := ContoursGeoJSON(contours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry struct {
+				Type        string
+				Coordinates [][2]float64
+			}
+			Properties map[string]float64
+		}
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 1 {
+		t.Fatalf("expected a FeatureCollection of 1 feature, got %s", data)
+	}
+	f := collection.Features[0]
+	if f.Type != "Feature" || f.Geometry.Type != "LineString" || f.Properties["level"] != 0.5 {
+		t.Errorf("expected a LineString feature at level 0.5, got %s", data)
+	}
+	coords := f.Geometry.Coordinates
+	if len(coords) <= len(contours[0].Points()) || coords[0][0] != -180 || coords[len(coords)-1][0] != 180 {
+		t.Errorf("expected the closed line to be cut at the antimeridian, got %v", coords)
+	}
+	if math.Abs(coords[0][1]-30) > 0.5 {
+		t.Errorf("expected latitude near 30 degrees, got %v", coords[0][1])
+	}
+}
+
+func TestContourLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		center   SphereCoordinate
+		open     bool
+		lines    int
+		geometry string
+	}{
+		{"away from the antimeridian", NewLatLonCoordinate(0.2, 1), false, 1, "LineString"},
+		{"across the antimeridian", NewLatLonCoordinate(0.2, math.Pi), false, 2, "MultiLineString"},
+		{"open across the antimeridian", NewLatLonCoordinate(-0.1, math.Pi+0.1), true, 3, "MultiLineString"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(4))
+			c := tc.center.ToVector()
+			m := sampleMap(hp, RingScheme, func(v Vector) float64 { return v.dot(c) })
+			if tc.open {
+				// a missing pixel to the south of the disc cuts the contour open
+				m[NewLatLonCoordinate(tc.center.Latitude()-0.3, tc.center.Longitude()).ToRingPixel(hp)] = math.NaN()
+			}
+			contours := Contours(hp, m, RingScheme, []float64{math.Cos(0.3)})[0]
+			if len(contours) != 1 || contours[0].Closed() == tc.open {
+				t.Fatalf("expected 1 contour, closed %v, got %d", !tc.open, len(contours))
+			}
+			lines := contours[0].Lines()
+			if len(lines) != tc.lines {
+				t.Fatalf("expected %d lines, got %d", tc.lines, len(lines))
+			}
+			for i, line := range lines {
+				for _, p := range line {
+					if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+						t.Errorf("position %v out of range", p)
+					}
+				}
+				// each line but the last ends on the antimeridian where the next starts on the other side
+				if i+1 < len(lines) {
+					end, start := line[len(line)-1], lines[i+1][0]
+					if math.Abs(end[0]) != 180 || start[0] != -end[0] || start[1] != end[1] {
+						t.Errorf("expected line %d to end at the antimeridian where the next starts, got %v and %v", i, end, start)
+					}
+				}
+			}
+			if first, last := lines[0][0], lines[len(lines)-1][len(lines[len(lines)-1])-1]; !tc.open && tc.lines == 1 && first != last {
+				t.Errorf("expected the closed line to repeat its first point %v, got %v", first, last)
+			}
+
+			data, err := json.Marshal(contours[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f struct{ Geometry struct{ Type string } }
+			if err := json.Unmarshal(data, &f); err != nil {
+				t.Fatal(err)
+			}
+			if f.Geometry.Type != tc.geometry {
+				t.Errorf("expected a %s geometry, got %s", tc.geometry, data)
+			}
+		})
+	}
+}
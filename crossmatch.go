@@ -0,0 +1,193 @@
+package healpix
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Selects which pairs CrossMatch returns.
+type CrossMatchMode int
+
+const (
+	// For each entry of the first list, return only the nearest entry of the second list within the radius.
+	BestMatch CrossMatchMode = iota
+	// Return every pair of entries within the radius.
+	AllMatches
+)
+
+// Configures a CrossMatch. The zero value finds the best match for each entry using all available processors.
+type CrossMatchOptions struct {
+	Mode CrossMatchMode
+	// The maximum number of goroutines to split the matching across. Values <= 0 use runtime.GOMAXPROCS(0).
+	Workers int
+	// The HEALPix map used to interpret any positions given as pixel indices. Not needed when all the
+	// positions are sphere coordinates, vectors or face coordinates.
+	Healpix Healpix
+}
+
+// A pair of entries matched by CrossMatch.
+type CrossMatchPair struct {
+	a        int
+	b        int
+	distance float64
+}
+
+// The index of the matched entry in the first list.
+func (m CrossMatchPair) A() int {
+	return m.a
+}
+
+// The index of the matched entry in the second list.
+func (m CrossMatchPair) B() int {
+	return m.b
+}
+
+// The angular distance in radians between the matched entries.
+func (m CrossMatchPair) Distance() float64 {
+	return m.distance
+}
+
+// The fraction of the angular resolution of a map that is always smaller than the distance from a point to
+// the outside of the 3x3 block of pixels around the pixel containing it. That distance was measured to be
+// just under 0.68 of the resolution for the narrowest pixels, near the poles, but only at sampled points, so
+// 0.5 leaves a margin for points and orders that were not sampled. The cost of the margin is that some radii
+// just below 0.68 of a resolution are matched at the next coarser order, with about four times as many
+// candidates per pixel.
+const crossMatchResolutionFraction = 0.5
+
+// Pair up the entries of the two position lists that lie within the given angular radius (in radians) of
+// each other. Both lists are bucketed by nest pixel at the finest order whose pixels are comfortably wider
+// than the radius, so every match of an entry lies in its own pixel or one of the neighbors of that pixel.
+// The pixels are matched concurrently, grouped by face. The result is deterministic: pairs are sorted by the
+// index in the first list, then by distance, then by the index in the second list. In BestMatch mode ties
+// in distance are broken by the lower index in the second list.
+func CrossMatch(a []Where, b []Where, radius float64, opts CrossMatchOptions) []CrossMatchPair {
+	av := make([]Vector, len(a))
+	for i, w := range a {
+		av[i] = toVector(opts.Healpix, w)
+	}
+	bv := make([]Vector, len(b))
+	for i, w := range b {
+		bv[i] = toVector(opts.Healpix, w)
+	}
+
+	order := crossMatchOrder(radius)
+	var result []CrossMatchPair
+	if order < 0 {
+		// the radius is too large for even the base pixels, so every pair has to be compared
+		result = matchCandidates(av, bv, allIndices(len(av)), allIndices(len(bv)), radius, opts.Mode)
+	} else {
+		result = matchByPixel(New(NewHealpixOrder(order)), av, bv, radius, opts)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].a != result[j].a {
+			return result[i].a < result[j].a
+		}
+		if result[i].distance != result[j].distance {
+			return result[i].distance < result[j].distance
+		}
+		return result[i].b < result[j].b
+	})
+	return result
+}
+
+// The finest order at which every match within radius of a point lies in the 3x3 block of pixels around it,
+// or -1 if the radius is too large even for order 0.
+func crossMatchOrder(radius float64) int {
+	order := -1
+	for o := 0; o <= MaxOrder(); o++ {
+		if radius > crossMatchResolutionFraction*New(HealpixOrder(o)).AngularResolution() {
+			break
+		}
+		order = o
+	}
+	return order
+}
+
+func matchByPixel(hp Healpix, av []Vector, bv []Vector, radius float64, opts CrossMatchOptions) []CrossMatchPair {
+	bBuckets := map[NestPixel][]int{}
+	for i, v := range bv {
+		pixel := v.ToNestPixel(hp)
+		bBuckets[pixel] = append(bBuckets[pixel], i)
+	}
+	// the first list is bucketed by face as well, so each face can be matched independently
+	aBuckets := [12]map[FacePixel][]int{}
+	for face := range aBuckets {
+		aBuckets[face] = map[FacePixel][]int{}
+	}
+	for i, v := range av {
+		fp := v.ToFacePixel(hp)
+		aBuckets[fp.face][fp] = append(aBuckets[fp.face][fp], i)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	faces := make(chan int)
+	results := [12][]CrossMatchPair{}
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, 12); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for face := range faces {
+				var neighbors [8]int64
+				for fp, aIndices := range aBuckets[face] {
+					candidates := append([]int{}, bBuckets[fp.ToNestPixel(hp)]...)
+					NeighborsInto(hp, fp, NestScheme, &neighbors)
+					for _, n := range neighbors {
+						if n >= 0 {
+							candidates = append(candidates, bBuckets[NestPixel(n)]...)
+						}
+					}
+					results[face] = append(results[face], matchCandidates(av, bv, aIndices, candidates, radius, opts.Mode)...)
+				}
+			}
+		}()
+	}
+	for face := range aBuckets {
+		faces <- face
+	}
+	close(faces)
+	wg.Wait()
+
+	result := []CrossMatchPair{}
+	for _, r := range results {
+		result = append(result, r...)
+	}
+	return result
+}
+
+// Compare every entry of the first list with every candidate of the second list.
+func matchCandidates(av []Vector, bv []Vector, aIndices []int, bIndices []int, radius float64, mode CrossMatchMode) []CrossMatchPair {
+	result := []CrossMatchPair{}
+	for _, i := range aIndices {
+		best := CrossMatchPair{i, -1, 0}
+		for _, j := range bIndices {
+			distance := angleBetween(av[i], bv[j])
+			if distance > radius {
+				continue
+			}
+			if mode == AllMatches {
+				result = append(result, CrossMatchPair{i, j, distance})
+			} else if best.b < 0 || distance < best.distance || (distance == best.distance && j < best.b) {
+				best = CrossMatchPair{i, j, distance}
+			}
+		}
+		if mode == BestMatch && best.b >= 0 {
+			result = append(result, best)
+		}
+	}
+	return result
+}
+
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
@@ -0,0 +1,121 @@
+package healpix
+
+import (
+	"maps"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The pairs within radius found by comparing every entry of a with every entry of b, keyed by their indices.
+// In BestMatch mode only the nearest entry of b is kept for each entry of a, the first one in case of ties.
+func bruteForceCrossMatch(a []Where, b []Where, radius float64, mode CrossMatchMode) map[[2]int]float64 {
+	bv := make([]Vector, len(b))
+	for j, w := range b {
+		bv[j] = toVector(Healpix{}, w)
+	}
+	result := map[[2]int]float64{}
+	for i, w := range a {
+		va := toVector(Healpix{}, w)
+		best, bestDistance := -1, math.Inf(1)
+		for j, vb := range bv {
+			distance := angleBetween(va, vb)
+			if distance > radius {
+				continue
+			}
+			if mode == AllMatches {
+				result[[2]int{i, j}] = distance
+			} else if distance < bestDistance {
+				best, bestDistance = j, distance
+			}
+		}
+		if best >= 0 {
+			result[[2]int{i, best}] = bestDistance
+		}
+	}
+	return result
+}
+
+func TestCrossMatchMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(35))
+	a := make([]Where, 2000)
+	for i := range a {
+		a[i] = randomPosition(rng)
+	}
+	b := make([]Where, 2000)
+	for i := range b {
+		b[i] = randomPosition(rng)
+	}
+	// include points very near the poles, where the pixels are narrowest
+	a = append(a, NewLatLonCoordinate(math.Pi/2-1e-4, 0.3), NewLatLonCoordinate(-math.Pi/2+1e-4, 2.0))
+	b = append(b, NewLatLonCoordinate(math.Pi/2-1e-4, 3.5), NewLatLonCoordinate(-math.Pi/2+2e-4, 5.0))
+
+	for _, radius := range []float64{0.001, 0.01, 0.05, 0.3, 1.0} {
+		for _, mode := range []CrossMatchMode{BestMatch, AllMatches} {
+			for _, workers := range []int{1, 4} {
+				expected := bruteForceCrossMatch(a, b, radius, mode)
+				found := map[[2]int]float64{}
+				for _, m := range CrossMatch(a, b, radius, CrossMatchOptions{Mode: mode, Workers: workers}) {
+					found[[2]int{m.A(), m.B()}] = m.Distance()
+				}
+				if !maps.Equal(found, expected) {
+					t.Errorf("Radius %v, mode %v, workers %v: expected %d pairs, got %d instead", radius, mode, workers, len(expected), len(found))
+				}
+			}
+		}
+	}
+}
+
+func TestCrossMatchOrder(t *testing.T) {
+	a := []Where{
+		NewLatLonCoordinate(0, 1),
+		NewLatLonCoordinate(0.5, 0),
+		NewLatLonCoordinate(0, 0),
+	}
+	b := []Where{
+		NewLatLonCoordinate(0, 0.02),
+		NewLatLonCoordinate(0, -0.01),
+		NewLatLonCoordinate(0, 1.005),
+		NewLatLonCoordinate(0, 0.01),
+		NewLatLonCoordinate(0, 0.99),
+	}
+	testCases := []struct {
+		name     string
+		mode     CrossMatchMode
+		expected [][2]int
+	}{
+		// by index in a, then by distance, then by index in b for the tie at 0.01 from entry 2
+		{"all matches", AllMatches, [][2]int{{0, 2}, {0, 4}, {2, 1}, {2, 3}, {2, 0}}},
+		{"best match", BestMatch, [][2]int{{0, 2}, {2, 1}}},
+	}
+	distances := map[[2]int]float64{{0, 2}: 0.005, {0, 4}: 0.01, {2, 1}: 0.01, {2, 3}: 0.01, {2, 0}: 0.02}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := CrossMatch(a, b, 0.03, CrossMatchOptions{Mode: tc.mode, Workers: 2})
+			if len(r) != len(tc.expected) {
+				t.Fatalf("Expected %d pairs, got %v instead", len(tc.expected), r)
+			}
+			for k, pair := range tc.expected {
+				if r[k].A() != pair[0] || r[k].B() != pair[1] || !withinTolerance(r[k].Distance(), distances[pair], 1e-9) {
+					t.Errorf("Pair %d: expected %v at distance %v, got %v instead", k, pair, distances[pair], r[k])
+				}
+			}
+		})
+	}
+}
+
+func TestCrossMatchBestMatchTies(t *testing.T) {
+	a := []Where{NewLatLonCoordinate(0, 0)}
+	b := []Where{NewLatLonCoordinate(0, 0.01), NewLatLonCoordinate(0, -0.01), NewLatLonCoordinate(0, 0.5)}
+	r := CrossMatch(a, b, 0.02, CrossMatchOptions{})
+	if len(r) != 1 || r[0].A() != 0 || r[0].B() != 0 {
+		t.Fatalf("Expected a single match with the lower index, got %v instead", r)
+	}
+	if !withinTolerance(r[0].Distance(), 0.01, 1e-9) {
+		t.Errorf("Distance expected %v, got %v instead", 0.01, r[0].Distance())
+	}
+	all := CrossMatch(a, b, 0.02, CrossMatchOptions{Mode: AllMatches})
+	if len(all) != 2 || all[0].B() != 0 || all[1].B() != 1 {
+		t.Errorf("Expected matches with entries 0 and 1, got %v instead", all)
+	}
+}
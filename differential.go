@@ -0,0 +1,225 @@
+package healpix
+
+import (
+	"fmt"
+	"math"
+)
+
+// Finite difference operators on scalar and vector maps. A map holds one value per pixel, indexed in the
+// given scheme. Around each pixel a quadratic surface is fit by least squares to the values of the pixel and
+// its neighbors, with the neighbor centers placed by distance and bearing from the pixel center (azimuthal
+// equidistant coordinates). These are normal coordinates of the sphere at the pixel center, so the first and
+// second derivatives of the fit give the gradient and Laplace-Beltrami operator there without curvature
+// corrections, and the uneven shapes of the pixels are accounted for by the actual neighbor positions.
+// Derivatives are with respect to angle on the unit sphere; divide by the radius (or its square, for the
+// Laplacian) for physical units.
+
+// The weights applied to the differences between each neighbor's value and the pixel's own value to get
+// each derivative at the pixel.
+type stencil struct {
+	neighbors [8]int64 // -1 for missing neighbors, which have zero weight
+	east      [8]float64
+	north     [8]float64
+	laplacian [8]float64
+	frame     [2]Vector // the east and north unit vectors at the pixel center
+}
+
+func newStencil(hp Healpix, fp FacePixel, scheme HealpixScheme) stencil {
+	s := stencil{}
+	NeighborsInto(hp, fp, scheme, &s.neighbors)
+	center := fp.ToSphereCoordinate(hp)
+	c := center.ToVector()
+	east, north := localFrame(c, center.Longitude())
+	s.frame = [2]Vector{east, north}
+
+	// scale the coordinates to about one pixel, so the normal equations are well conditioned
+	scale := hp.AngularResolution()
+	rows := [8][5]float64{}
+	for d, n := range s.neighbors {
+		if n < 0 {
+			continue
+		}
+		q := schemePixel(hp, uint(n), scheme).ToSphereCoordinate(hp).ToVector()
+		direction := q.add(c.scale(-c.dot(q)))
+		distance := angleBetween(c, q) / (scale * direction.Length())
+		u := direction.dot(east) * distance
+		v := direction.dot(north) * distance
+		rows[d] = [5]float64{u, v, u * u / 2, u * v, v * v / 2}
+	}
+
+	// normal equations of the least squares fit of the differences
+	normal := [5][5]float64{}
+	for _, row := range rows {
+		for i := range row {
+			for j := range row {
+				normal[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	inverse := invert5(normal)
+	for d := range rows {
+		for k := 0; k < 5; k++ {
+			s.east[d] += inverse[0][k] * rows[d][k] / scale
+			s.north[d] += inverse[1][k] * rows[d][k] / scale
+			s.laplacian[d] += (inverse[2][k] + inverse[4][k]) * rows[d][k] / (scale * scale)
+		}
+	}
+	return s
+}
+
+// Invert a symmetric positive definite 5x5 matrix by Gauss-Jordan elimination with partial pivoting.
+func invert5(m [5][5]float64) [5][5]float64 {
+	inv := [5][5]float64{}
+	for i := range inv {
+		inv[i][i] = 1
+	}
+	for col := 0; col < 5; col++ {
+		pivot := col
+		for row := col + 1; row < 5; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+		p := m[col][col]
+		for j := 0; j < 5; j++ {
+			m[col][j] /= p
+			inv[col][j] /= p
+		}
+		for row := 0; row < 5; row++ {
+			if row == col {
+				continue
+			}
+			f := m[row][col]
+			for j := 0; j < 5; j++ {
+				m[row][j] -= f * m[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv
+}
+
+// The face pixel with the given index in the given scheme.
+func schemePixel(hp Healpix, id uint, scheme HealpixScheme) FacePixel {
+	if scheme == RingScheme {
+		return RingPixel(id).ToFacePixel(hp)
+	}
+	return NestPixel(id).ToFacePixel(hp)
+}
+
+func checkMapSize(hp Healpix, m []float64) {
+	if uint(len(m)) != hp.Pixels() {
+		panic(fmt.Sprintf("healpix: map has %d values but the HEALPix map has %d pixels", len(m), hp.Pixels()))
+	}
+}
+
+// The finite difference stencils of every pixel of a HEALPix map indexed in one scheme, which the Gradient,
+// Divergence and Laplacian functions compute anew on each call. Computing the stencils costs far more than
+// applying them, so compute them once and reuse them to differentiate many maps of the same resolution; they
+// take about 300 bytes per pixel.
+type Stencils struct {
+	hp       Healpix
+	scheme   HealpixScheme
+	stencils []stencil
+}
+
+// Compute the stencils of every pixel of the HEALPix map indexed in the given scheme.
+func NewStencils(hp Healpix, scheme HealpixScheme) Stencils {
+	stencils := make([]stencil, hp.Pixels())
+	for p := range stencils {
+		stencils[p] = newStencil(hp, schemePixel(hp, uint(p), scheme), scheme)
+	}
+	return Stencils{hp, scheme, stencils}
+}
+
+// The HEALPix map the stencils are for.
+func (st Stencils) Healpix() Healpix {
+	return st.hp
+}
+
+// The index scheme of the maps the stencils apply to.
+func (st Stencils) Scheme() HealpixScheme {
+	return st.scheme
+}
+
+// Return the eastward and northward components of the gradient of the scalar map at each pixel center, in
+// units of the map per radian. Panics if the map does not have one value per pixel.
+func (st Stencils) Gradient(m []float64) ([]float64, []float64) {
+	checkMapSize(st.hp, m)
+	east := make([]float64, len(m))
+	north := make([]float64, len(m))
+	for p := range st.stencils {
+		s := &st.stencils[p]
+		for d, n := range s.neighbors {
+			if n >= 0 {
+				diff := m[n] - m[p]
+				east[p] += s.east[d] * diff
+				north[p] += s.north[d] * diff
+			}
+		}
+	}
+	return east, north
+}
+
+// Return the divergence at each pixel center of the tangent vector field with the given eastward and
+// northward components, in units of the field per radian. The neighbors' vectors are projected into the
+// tangent plane at each pixel center before differencing. Panics if the maps do not have one value per pixel.
+func (st Stencils) Divergence(east []float64, north []float64) []float64 {
+	checkMapSize(st.hp, east)
+	checkMapSize(st.hp, north)
+	div := make([]float64, len(east))
+	for p := range st.stencils {
+		s := &st.stencils[p]
+		for d, n := range s.neighbors {
+			if n < 0 {
+				continue
+			}
+			frame := st.stencils[n].frame
+			v := frame[0].scale(east[n]).add(frame[1].scale(north[n]))
+			div[p] += s.east[d]*(v.dot(s.frame[0])-east[p]) + s.north[d]*(v.dot(s.frame[1])-north[p])
+		}
+	}
+	return div
+}
+
+// Return the Laplacian (Laplace-Beltrami operator) of the scalar map at each pixel center, in units of the
+// map per square radian. Panics if the map does not have one value per pixel.
+func (st Stencils) Laplacian(m []float64) []float64 {
+	checkMapSize(st.hp, m)
+	lap := make([]float64, len(m))
+	for p := range st.stencils {
+		s := &st.stencils[p]
+		for d, n := range s.neighbors {
+			if n >= 0 {
+				lap[p] += s.laplacian[d] * (m[n] - m[p])
+			}
+		}
+	}
+	return lap
+}
+
+// Return the eastward and northward components of the gradient of the scalar map at each pixel center, in
+// units of the map per radian. Panics if the map does not have one value per pixel. See Stencils to
+// differentiate many maps.
+func Gradient(hp Healpix, m []float64, scheme HealpixScheme) ([]float64, []float64) {
+	checkMapSize(hp, m)
+	return NewStencils(hp, scheme).Gradient(m)
+}
+
+// Return the divergence at each pixel center of the tangent vector field with the given eastward and
+// northward components, as for Stencils.Divergence. Panics if the maps do not have one value per pixel.
+func Divergence(hp Healpix, east []float64, north []float64, scheme HealpixScheme) []float64 {
+	checkMapSize(hp, east)
+	checkMapSize(hp, north)
+	return NewStencils(hp, scheme).Divergence(east, north)
+}
+
+// Return the Laplacian (Laplace-Beltrami operator) of the scalar map at each pixel center, in units of the
+// map per square radian. Panics if the map does not have one value per pixel. See Stencils to differentiate
+// many maps.
+func Laplacian(hp Healpix, m []float64, scheme HealpixScheme) []float64 {
+	checkMapSize(hp, m)
+	return NewStencils(hp, scheme).Laplacian(m)
+}
@@ -0,0 +1,161 @@
+package healpix
+
+import (
+	"math"
+	"testing"
+)
+
+// Sample the function at the center of every pixel, indexed in the given scheme.
+func sampleMap(hp Healpix, scheme HealpixScheme, f func(Vector) float64) []float64 {
+	m := make([]float64, hp.Pixels())
+	for p, center := range hp.AllPixelCenters(scheme) {
+		m[p] = f(center.ToVector())
+	}
+	return m
+}
+
+func TestGradient(t *testing.T) {
+	testCases := []struct {
+		name      string
+		order     int
+		scheme    HealpixScheme
+		tolerance float64
+	}{
+		{"order 3 nest", 3, NestScheme, 2e-2},
+		{"order 3 ring", 3, RingScheme, 2e-2},
+		{"order 5 nest", 5, NestScheme, 2e-3},
+		{"order 5 ring", 5, RingScheme, 2e-3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			m := sampleMap(hp, tc.scheme, func(v Vector) float64 { return v.x * v.z })
+			east, north := Gradient(hp, m, tc.scheme)
+			for p, center := range hp.AllPixelCenters(tc.scheme) {
+				v := center.ToVector()
+				e, n := localFrame(v, center.Longitude())
+				// the gradient of x*z is (z, 0, x), projected onto the tangent plane
+				g := Vector{v.z, 0, v.x}
+				if math.Abs(east[p]-g.dot(e)) > tc.tolerance || math.Abs(north[p]-g.dot(n)) > tc.tolerance {
+					t.Fatalf("pixel %d: expected gradient (%v, %v), got (%v, %v)", p, g.dot(e), g.dot(n), east[p], north[p])
+				}
+			}
+		})
+	}
+}
+
+func TestGradientOfLatitude(t *testing.T) {
+	// z = sin(latitude) increases northward at the rate cos(latitude), and not at all eastward
+	hp := New(NewHealpixOrder(4))
+	m := sampleMap(hp, RingScheme, func(v Vector) float64 { return v.z })
+	east, north := Gradient(hp, m, RingScheme)
+	for p, center := range hp.AllPixelCenters(RingScheme) {
+		if math.Abs(east[p]) > 5e-3 || math.Abs(north[p]-math.Cos(center.Latitude())) > 5e-3 {
+			t.Fatalf("pixel %d: expected gradient (0, %v), got (%v, %v)", p, math.Cos(center.Latitude()), east[p], north[p])
+		}
+	}
+}
+
+func TestLaplacian(t *testing.T) {
+	testCases := []struct {
+		name       string
+		order      int
+		scheme     HealpixScheme
+		f          func(Vector) float64
+		eigenvalue float64
+		tolerance  float64
+	}{
+		{"constant", 3, NestScheme, func(v Vector) float64 { return 1 }, 0, 1e-9},
+		{"z order 3", 3, RingScheme, func(v Vector) float64 { return v.z }, -2, 5e-2},
+		{"xz order 3", 3, NestScheme, func(v Vector) float64 { return v.x * v.z }, -6, 1e-1},
+		{"xz order 5", 5, RingScheme, func(v Vector) float64 { return v.x * v.z }, -6, 5e-2},
+		{"xyz order 5", 5, NestScheme, func(v Vector) float64 { return v.x * v.y * v.z }, -12, 5e-2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			m := sampleMap(hp, tc.scheme, tc.f)
+			lap := Laplacian(hp, m, tc.scheme)
+			for p := range m {
+				if math.Abs(lap[p]-tc.eigenvalue*m[p]) > tc.tolerance {
+					t.Fatalf("pixel %d: expected Laplacian %v, got %v", p, tc.eigenvalue*m[p], lap[p])
+				}
+			}
+		})
+	}
+}
+
+func TestDivergenceOfGradient(t *testing.T) {
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		hp := New(NewHealpixOrder(5))
+		m := sampleMap(hp, scheme, func(v Vector) float64 { return v.x * v.z })
+		east, north := Gradient(hp, m, scheme)
+		div := Divergence(hp, east, north, scheme)
+		for p := range m {
+			if math.Abs(div[p]+6*m[p]) > 2e-2 {
+				t.Fatalf("pixel %d: expected divergence of gradient %v, got %v", p, -6*m[p], div[p])
+			}
+		}
+	}
+}
+
+func TestDivergenceOfRotation(t *testing.T) {
+	// rigid rotation about the z axis is divergence free
+	hp := New(NewHealpixOrder(4))
+	east := sampleMap(hp, NestScheme, func(v Vector) float64 { return math.Hypot(v.x, v.y) })
+	north := make([]float64, len(east))
+	div := Divergence(hp, east, north, NestScheme)
+	for p := range div {
+		if math.Abs(div[p]) > 5e-3 {
+			t.Fatalf("pixel %d: expected zero divergence, got %v", p, div[p])
+		}
+	}
+}
+
+func TestStencilsReused(t *testing.T) {
+	testCases := []struct {
+		name       string
+		f          func(Vector) float64
+		gradient   func(Vector) Vector // the gradient in space, projected onto the tangent plane in the test
+		eigenvalue float64
+	}{
+		{"z", func(v Vector) float64 { return v.z }, func(v Vector) Vector { return Vector{0, 0, 1} }, -2},
+		{"xy", func(v Vector) float64 { return v.x * v.y }, func(v Vector) Vector { return Vector{v.y, v.x, 0} }, -6},
+		{"xz", func(v Vector) float64 { return v.x * v.z }, func(v Vector) Vector { return Vector{v.z, 0, v.x} }, -6},
+	}
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		hp := New(NewHealpixOrder(4))
+		// one set of stencils differentiates every map
+		stencils := NewStencils(hp, scheme)
+		if stencils.Healpix() != hp || stencils.Scheme() != scheme {
+			t.Errorf("expected stencils for %v in scheme %v", hp, scheme)
+		}
+		for _, tc := range testCases {
+			m := sampleMap(hp, scheme, tc.f)
+			east, north := stencils.Gradient(m)
+			lap := stencils.Laplacian(m)
+			div := stencils.Divergence(east, north)
+			for p, center := range hp.AllPixelCenters(scheme) {
+				v := center.ToVector()
+				e, n := localFrame(v, center.Longitude())
+				g := tc.gradient(v)
+				if math.Abs(east[p]-g.dot(e)) > 1e-2 || math.Abs(north[p]-g.dot(n)) > 1e-2 {
+					t.Fatalf("%v, pixel %d: expected gradient (%v, %v), got (%v, %v)", tc.name, p, g.dot(e), g.dot(n), east[p], north[p])
+				}
+				if math.Abs(lap[p]-tc.eigenvalue*m[p]) > 1e-1 || math.Abs(div[p]-tc.eigenvalue*m[p]) > 1e-1 {
+					t.Fatalf("%v, pixel %d: expected Laplacian and divergence of gradient %v, got %v and %v", tc.name, p, tc.eigenvalue*m[p], lap[p], div[p])
+				}
+			}
+		}
+	}
+}
+
+func TestDifferentialMapSizePanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for map of the wrong size")
+		}
+	}()
+	Laplacian(hp, make([]float64, 10), NestScheme)
+}
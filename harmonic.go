@@ -0,0 +1,185 @@
+package healpix
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// A minimal spherical harmonic transform of real maps, used to apply operators that are diagonal in harmonic
+// space. Coefficients are stored for m >= 0 only, as the coefficients of a real map with negative m are the
+// conjugates of those with positive m. The transforms sum over rings directly rather than by FFT, so each one
+// costs on the order of Pixels() times lmax operations, which suits small and medium maps.
+
+// The most Jacobi iterations used to refine the coefficients of a map. The iteration stops early once the
+// residual map is down to rounding error, which for band limited maps with lmax up to NSide takes a handful
+// of iterations and up to twice NSide around twenty.
+const maxHarmonicIterations = 30
+
+// Spherical harmonic coefficients of a real map, up to and including degree lmax.
+type harmonicCoefficients struct {
+	lmax   int
+	coeffs []complex128
+}
+
+func newHarmonicCoefficients(lmax int) harmonicCoefficients {
+	return harmonicCoefficients{lmax, make([]complex128, (lmax+1)*(lmax+2)/2)}
+}
+
+// The position of the coefficient of degree l and order m, grouped by order as in healpy.
+func (a harmonicCoefficients) index(l int, m int) int {
+	return m*(2*a.lmax+1-m)/2 + l
+}
+
+// Fill lambda, indexed like the harmonic coefficients, with the orthonormal associated Legendre functions
+// such that Y_lm(colatitude, longitude) = lambda[l, m] * exp(i m longitude), for the given cosine and sine of
+// the colatitude.
+func (a harmonicCoefficients) legendre(z float64, s float64, lambda []float64) {
+	mm := 1 / math.Sqrt(4*math.Pi)
+	for m := 0; m <= a.lmax; m++ {
+		if m > 0 {
+			mm *= -math.Sqrt(float64(2*m+1)/float64(2*m)) * s
+		}
+		i := a.index(m, m)
+		lambda[i] = mm
+		if m == a.lmax {
+			break
+		}
+		lambda[i+1] = math.Sqrt(float64(2*m+3)) * z * mm
+		for l := m + 2; l <= a.lmax; l++ {
+			fl, fm := float64(l), float64(m)
+			prev := math.Sqrt(((fl-1)*(fl-1) - fm*fm) / (4*(fl-1)*(fl-1) - 1))
+			lambda[i+l-m] = math.Sqrt((4*fl*fl-1)/(fl*fl-fm*fm)) * (z*lambda[i+l-m-1] - prev*lambda[i+l-m-2])
+		}
+	}
+}
+
+// Add the quadrature estimate of the coefficients of the ring ordered map to the coefficients.
+func (a harmonicCoefficients) analyze(hp Healpix, m []float64) {
+	weight := 4 * math.Pi / float64(hp.Pixels())
+	lambda := make([]float64, len(a.coeffs))
+	sums := make([]complex128, a.lmax+1)
+	for ring := range hp.AllRings() {
+		a.legendre(ring.Z(), ring.sinColatitude(), lambda)
+		first := int(ring.FirstIndex())
+		step := 2 * math.Pi / float64(ring.Pixels())
+		for order := range sums {
+			sum := complex(0, 0)
+			rotation := cmplx.Rect(1, -float64(order)*step)
+			phase := cmplx.Rect(1, -float64(order)*ring.Phi0())
+			for j := 0; j < ring.Pixels(); j++ {
+				sum += complex(m[first+j], 0) * phase
+				phase *= rotation
+			}
+			sums[order] = sum * complex(weight, 0)
+		}
+		for order, sum := range sums {
+			for l := order; l <= a.lmax; l++ {
+				i := a.index(l, order)
+				a.coeffs[i] += complex(lambda[i], 0) * sum
+			}
+		}
+	}
+}
+
+// Return the ring ordered map synthesized from the coefficients.
+func (a harmonicCoefficients) synthesize(hp Healpix) []float64 {
+	m := make([]float64, hp.Pixels())
+	lambda := make([]float64, len(a.coeffs))
+	sums := make([]complex128, a.lmax+1)
+	for ring := range hp.AllRings() {
+		a.legendre(ring.Z(), ring.sinColatitude(), lambda)
+		for order := range sums {
+			sum := complex(0, 0)
+			for l := order; l <= a.lmax; l++ {
+				i := a.index(l, order)
+				sum += complex(lambda[i], 0) * a.coeffs[i]
+			}
+			sums[order] = sum
+		}
+		first := int(ring.FirstIndex())
+		step := 2 * math.Pi / float64(ring.Pixels())
+		for order, sum := range sums {
+			factor := 2.0
+			if order == 0 {
+				factor = 1
+			}
+			rotation := cmplx.Rect(1, float64(order)*step)
+			phase := cmplx.Rect(1, float64(order)*ring.Phi0())
+			for j := 0; j < ring.Pixels(); j++ {
+				m[first+j] += factor * real(sum*phase)
+				phase *= rotation
+			}
+		}
+	}
+	return m
+}
+
+// Return the coefficients of the ring ordered map, refined by Jacobi iteration on the residual map.
+func mapToHarmonics(hp Healpix, m []float64, lmax int) harmonicCoefficients {
+	a := newHarmonicCoefficients(lmax)
+	a.analyze(hp, m)
+	scale := 0.0
+	for _, v := range m {
+		scale = max(scale, math.Abs(v))
+	}
+	residual := make([]float64, len(m))
+	for iteration := 0; iteration < maxHarmonicIterations; iteration++ {
+		synthesized := a.synthesize(hp)
+		largest := 0.0
+		for p := range residual {
+			residual[p] = m[p] - synthesized[p]
+			largest = max(largest, math.Abs(residual[p]))
+		}
+		if largest <= 1e-14*scale {
+			break
+		}
+		a.analyze(hp, residual)
+	}
+	return a
+}
+
+// Return the Laplacian of the scalar map computed in harmonic space, in units of the map per square radian.
+// The map is expanded in spherical harmonics up to degree lmax, each coefficient of degree l is scaled by its
+// eigenvalue -l(l+1), and the result is synthesized back at the pixel centers. Unlike Laplacian this is exact
+// for smooth maps with no power above lmax, which should be at most about twice NSide for the expansion to
+// converge; power above lmax is discarded. Panics if the map does not have one value per pixel or lmax is
+// negative.
+func HarmonicLaplacian(hp Healpix, m []float64, scheme HealpixScheme, lmax int) []float64 {
+	checkMapSize(hp, m)
+	if lmax < 0 {
+		panic(fmt.Sprintf("healpix: harmonic degree %d must not be negative", lmax))
+	}
+	rings := toRingOrder(hp, m, scheme)
+	a := mapToHarmonics(hp, rings, lmax)
+	for order := 0; order <= lmax; order++ {
+		for l := order; l <= lmax; l++ {
+			a.coeffs[a.index(l, order)] *= complex(-float64(l*(l+1)), 0)
+		}
+	}
+	return fromRingOrder(hp, a.synthesize(hp), scheme)
+}
+
+// Return the map reindexed from the given scheme into ring order, or the map itself if it already is.
+func toRingOrder(hp Healpix, m []float64, scheme HealpixScheme) []float64 {
+	if scheme == RingScheme {
+		return m
+	}
+	rings := make([]float64, len(m))
+	for p, v := range m {
+		rings[NestPixel(p).PixelId(hp, RingScheme)] = v
+	}
+	return rings
+}
+
+// Return the ring ordered map reindexed into the given scheme, or the map itself for the ring scheme.
+func fromRingOrder(hp Healpix, rings []float64, scheme HealpixScheme) []float64 {
+	if scheme == RingScheme {
+		return rings
+	}
+	m := make([]float64, len(rings))
+	for p, v := range rings {
+		m[RingPixel(p).PixelId(hp, NestScheme)] = v
+	}
+	return m
+}
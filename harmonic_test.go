@@ -0,0 +1,71 @@
+package healpix
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHarmonicLaplacian(t *testing.T) {
+	testCases := []struct {
+		name       string
+		order      int
+		scheme     HealpixScheme
+		lmax       int
+		f          func(Vector) float64
+		eigenvalue float64
+	}{
+		{"constant", 2, RingScheme, 8, func(v Vector) float64 { return 3 }, 0},
+		{"z", 2, NestScheme, 8, func(v Vector) float64 { return v.z }, -2},
+		{"xz", 3, RingScheme, 16, func(v Vector) float64 { return v.x * v.z }, -6},
+		{"xyz", 3, NestScheme, 16, func(v Vector) float64 { return v.x * v.y * v.z }, -12},
+		{"degree 7", 3, RingScheme, 12, func(v Vector) float64 {
+			// the real part of (x + iy)^7 is a harmonic polynomial of degree 7
+			return real(complex(v.x, v.y) * complex(v.x, v.y) * complex(v.x, v.y) * complex(v.x, v.y) *
+				complex(v.x, v.y) * complex(v.x, v.y) * complex(v.x, v.y))
+		}, -56},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			m := sampleMap(hp, tc.scheme, tc.f)
+			lap := HarmonicLaplacian(hp, m, tc.scheme, tc.lmax)
+			for p := range m {
+				if math.Abs(lap[p]-tc.eigenvalue*m[p]) > 1e-6 {
+					t.Fatalf("pixel %d: expected Laplacian %v, got %v", p, tc.eigenvalue*m[p], lap[p])
+				}
+			}
+		})
+	}
+}
+
+func TestHarmonicRoundTrip(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	lmax := 8
+	a := newHarmonicCoefficients(lmax)
+	for m := 0; m <= lmax; m++ {
+		for l := m; l <= lmax; l++ {
+			im := float64(l - m)
+			if m == 0 {
+				// the coefficients of a real map with m = 0 are real
+				im = 0
+			}
+			a.coeffs[a.index(l, m)] = complex(float64(l+1), im)
+		}
+	}
+	b := mapToHarmonics(hp, a.synthesize(hp), lmax)
+	for i := range a.coeffs {
+		if math.Abs(real(a.coeffs[i])-real(b.coeffs[i])) > 1e-8 || math.Abs(imag(a.coeffs[i])-imag(b.coeffs[i])) > 1e-8 {
+			t.Fatalf("coefficient %d: expected %v, got %v", i, a.coeffs[i], b.coeffs[i])
+		}
+	}
+}
+
+func TestHarmonicLaplacianPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for negative lmax")
+		}
+	}()
+	HarmonicLaplacian(hp, make([]float64, hp.Pixels()), RingScheme, -1)
+}
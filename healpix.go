@@ -144,3 +144,35 @@ func (o Healpix) PixelSurfaceArea(radius float64) float64 {
 func (o Healpix) AngularResolution() float64 {
 	return math.Sqrt(o.PixelArea())
 }
+
+// Returns the largest angular distance in radians between the center of any pixel in the HEALPix map and
+// any of its vertices, as computed by max_pixrad in the reference implementation. Every point of the
+// sphere lies within this distance of the center of the pixel containing it.
+func (o Healpix) MaxPixelRadius() float64 {
+	nside := float64(o.FaceSidePixels())
+	// the largest pixels are found where the equatorial belt meets the polar caps
+	a := NewColatLonCoordinate(math.Acos(2.0/3.0), math.Pi/(4*nside)).ToVector()
+	t := 1 - 1/nside
+	b := NewColatLonCoordinate(math.Acos(1-t*t/3), 0).ToVector()
+	return angleBetween(a, b)
+}
+
+// Returns the range of pixel edge lengths and aspect ratios over every pixel in the HEALPix map, measured
+// as angles in radians. The pixels all have the same area, but their shapes vary considerably, especially
+// in the polar caps. Every pixel of the polar caps is measured, so the cost grows with the number of
+// pixels in the map.
+func (o Healpix) PixelShape() PixelShape {
+	return o.PixelShapeWith(SphereCoordinate.AngularDistance)
+}
+
+// Returns the range of pixel edge lengths and aspect ratios over every pixel in the HEALPix map, measured
+// with the given distance function between two positions. This allows measuring the pixels as laid out
+// on a body other than the unit sphere.
+func (o Healpix) PixelShapeWith(distance func(SphereCoordinate, SphereCoordinate) float64) PixelShape {
+	// the map is symmetric about the equator, so the southern rings mirror the northern ones
+	shape := NewRing(o, 0).PixelShapeWith(distance)
+	for ring := 1; ring <= o.EquatorRing(); ring++ {
+		shape = shape.merge(NewRing(o, ring).PixelShapeWith(distance))
+	}
+	return shape
+}
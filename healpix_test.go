@@ -1,6 +1,7 @@
 package healpix
 
 import (
+	"math"
 	"testing"
 )
 
@@ -114,3 +115,37 @@ func TestHealpixPolarRegionPixels(t *testing.T) {
 		})
 	}
 }
+
+func TestHealpixMaxPixelRadius(t *testing.T) {
+	for order := 0; order <= 5; order++ {
+		hp := New(NewHealpixOrder(order))
+		largest := 0.0
+		for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+			center := nest.ToSphereCoordinate(hp)
+			for _, vertex := range nest.ToFacePixel(hp).Vertices(hp) {
+				largest = max(largest, center.AngularDistance(vertex))
+			}
+		}
+		if !withinTolerance(hp.MaxPixelRadius(), largest, 1e-9) {
+			t.Errorf("Order %d: expected %v, got %v instead", order, largest, hp.MaxPixelRadius())
+		}
+	}
+}
+
+func TestHealpixPixelShapeMatchesAllPixels(t *testing.T) {
+	for order := 0; order <= 4; order++ {
+		hp := New(NewHealpixOrder(order))
+		expected := PixelShape{math.Inf(1), 0, math.Inf(1), 0}
+		for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+			expected = expected.merge(pixelShape(nest.ToFacePixel(hp).Vertices(hp), SphereCoordinate.AngularDistance))
+		}
+		r := hp.PixelShape()
+		if !withinTolerance(r.MinEdge(), expected.MinEdge(), 1e-9) || !withinTolerance(r.MaxEdge(), expected.MaxEdge(), 1e-9) ||
+			!withinTolerance(r.MinAspect(), expected.MinAspect(), 1e-9) || !withinTolerance(r.MaxAspect(), expected.MaxAspect(), 1e-9) {
+			t.Errorf("Order %d: expected %v, got %v instead", order, expected, r)
+		}
+		if r.MinAspect() < 1 || r.MinEdge() <= 0 || r.MaxEdge() > 2*hp.MaxPixelRadius() {
+			t.Errorf("Order %d: shape %v out of range", order, r)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package healpix
+
+import (
+	"math/bits"
+)
+
+// Navigation of the nested quadtree. Each pixel at one order is divided into exactly four pixels at the next
+// order, and in the nest scheme the four children of pixel p are numbered 4p to 4p+3 regardless of face. The
+// NUNIQ scheme shares this property, so the same bit shifts work for both pixel types. NestPixel does not know
+// its own order, so the methods that need it take the Healpix map the pixel belongs to.
+
+// The pixel containing this pixel the given number of levels (orders) up the quadtree. Panics if levels is
+// negative or greater than MaxOrder, like UniquePixel.Parent. A NestPixel does not know its own order, so use
+// Ancestor to also check against ascending past order 0 of the pixel's map.
+func (p NestPixel) Parent(levels int) NestPixel {
+	ascentLevels(MaxOrder(), MaxOrder()-levels)
+	return p >> (2 * levels)
+}
+
+// The four pixels at the next order that divide this pixel, from the southernmost to the northernmost.
+func (p NestPixel) Children() [4]NestPixel {
+	first := p << 2
+	return [4]NestPixel{first, first + 1, first + 2, first + 3}
+}
+
+// The pixel with the lowest index at the given (finer) order that lies within this pixel of the given map.
+// Together with LastChild this gives the contiguous range of nest indices covered by the pixel at that order.
+func (p NestPixel) FirstChild(hp Healpix, order int) NestPixel {
+	levels := descentLevels(hp.Order(), order)
+	return p << (2 * levels)
+}
+
+// The pixel with the highest index at the given (finer) order that lies within this pixel of the given map.
+func (p NestPixel) LastChild(hp Healpix, order int) NestPixel {
+	levels := descentLevels(hp.Order(), order)
+	return ((p + 1) << (2 * levels)) - 1
+}
+
+// The four pixels sharing the same parent as this pixel, including the pixel itself.
+func (p NestPixel) Siblings() [4]NestPixel {
+	return p.Parent(1).Children()
+}
+
+// The pixel at the given (coarser) order that contains this pixel of the given map.
+func (p NestPixel) Ancestor(hp Healpix, order int) NestPixel {
+	return p.Parent(ascentLevels(hp.Order(), order))
+}
+
+// Whether this pixel of the given map lies within the ancestor pixel at the given (coarser or equal) order.
+func (p NestPixel) IsDescendantOf(hp Healpix, ancestor NestPixel, order int) bool {
+	if order < 0 || order > hp.Order() {
+		return false
+	}
+	return p.Parent(hp.Order()-order) == ancestor
+}
+
+// The order of the HEALPix map at which this pixel is defined, decoded from the pixel value.
+func (p UniquePixel) Order() int {
+	return (bits.Len(uint(p)) - 3) / 2
+}
+
+// The nest scheme index of this pixel within the map at the pixel's own order.
+func (p UniquePixel) NestIndex() NestPixel {
+	return NestPixel(uint(p) - 4<<(2*p.Order()))
+}
+
+// The pixel containing this pixel the given number of levels (orders) up the quadtree. Panics if
+// this would ascend past order 0.
+func (p UniquePixel) Parent(levels int) UniquePixel {
+	ascentLevels(p.Order(), p.Order()-levels)
+	return p >> (2 * levels)
+}
+
+// The four pixels at the next order that divide this pixel, from the southernmost to the northernmost.
+func (p UniquePixel) Children() [4]UniquePixel {
+	descentLevels(p.Order(), p.Order()+1)
+	first := p << 2
+	return [4]UniquePixel{first, first + 1, first + 2, first + 3}
+}
+
+// The pixel with the lowest index at the given (finer) order that lies within this pixel.
+func (p UniquePixel) FirstChild(order int) UniquePixel {
+	levels := descentLevels(p.Order(), order)
+	return p << (2 * levels)
+}
+
+// The pixel with the highest index at the given (finer) order that lies within this pixel.
+func (p UniquePixel) LastChild(order int) UniquePixel {
+	levels := descentLevels(p.Order(), order)
+	return ((p + 1) << (2 * levels)) - 1
+}
+
+// The four pixels sharing the same parent as this pixel, including the pixel itself. Pixels at order 0
+// have no parent, so their siblings are taken to be the four base pixels in the same row of faces.
+func (p UniquePixel) Siblings() [4]UniquePixel {
+	first := p &^ 3
+	return [4]UniquePixel{first, first + 1, first + 2, first + 3}
+}
+
+// The pixel at the given (coarser) order that contains this pixel.
+func (p UniquePixel) Ancestor(order int) UniquePixel {
+	return p >> (2 * ascentLevels(p.Order(), order))
+}
+
+// Whether this pixel lies within the given pixel, which may be at any coarser or equal order.
+func (p UniquePixel) IsDescendantOf(ancestor UniquePixel) bool {
+	levels := p.Order() - ancestor.Order()
+	if levels < 0 {
+		return false
+	}
+	return p>>(2*levels) == ancestor
+}
+
+// The number of levels between a pixel's order and a coarser order. Panics if the order is not coarser.
+func ascentLevels(from int, to int) int {
+	if to < 0 || to > from {
+		panic("healpix: ancestor order must be between 0 and the order of the pixel")
+	}
+	return from - to
+}
+
+// The number of levels between a pixel's order and a finer order. Panics if the order is not finer.
+func descentLevels(from int, to int) int {
+	if to < from || to > MaxOrder() {
+		panic("healpix: descendant order must be between the order of the pixel and the maximum order")
+	}
+	return to - from
+}
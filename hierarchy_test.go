@@ -0,0 +1,135 @@
+package healpix
+
+import (
+	"testing"
+)
+
+func TestNestPixelHierarchy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		order    int
+		pixel    NestPixel
+		parent   NestPixel
+		children [4]NestPixel
+		siblings [4]NestPixel
+	}{
+		{"Order 1: pixel 0", 1, 0, 0, [4]NestPixel{0, 1, 2, 3}, [4]NestPixel{0, 1, 2, 3}},
+		{"Order 1: pixel 6", 1, 6, 1, [4]NestPixel{24, 25, 26, 27}, [4]NestPixel{4, 5, 6, 7}},
+		{"Order 1: pixel 47", 1, 47, 11, [4]NestPixel{188, 189, 190, 191}, [4]NestPixel{44, 45, 46, 47}},
+		{"Order 2: pixel 100", 2, 100, 25, [4]NestPixel{400, 401, 402, 403}, [4]NestPixel{100, 101, 102, 103}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if r := tc.pixel.Parent(1); r != tc.parent {
+				t.Errorf("Parent expected %v, got %v instead", tc.parent, r)
+			}
+			if r := tc.pixel.Children(); r != tc.children {
+				t.Errorf("Children expected %v, got %v instead", tc.children, r)
+			}
+			if r := tc.pixel.Siblings(); r != tc.siblings {
+				t.Errorf("Siblings expected %v, got %v instead", tc.siblings, r)
+			}
+		})
+	}
+}
+
+func TestNestPixelHierarchyMatchesPositions(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+		center := nest.ToSphereCoordinate(hp)
+		for order := 0; order <= hp.Order(); order++ {
+			coarse := New(NewHealpixOrder(order))
+			expected := center.ToNestPixel(coarse)
+			if r := nest.Ancestor(hp, order); r != expected {
+				t.Fatalf("Ancestor of %v at order %d expected %v, got %v instead", nest, order, expected, r)
+			}
+			if !nest.IsDescendantOf(hp, expected, order) {
+				t.Fatalf("Pixel %v expected to be a descendant of %v at order %d", nest, expected, order)
+			}
+			if nest < expected.FirstChild(coarse, hp.Order()) || nest > expected.LastChild(coarse, hp.Order()) {
+				t.Fatalf("Pixel %v expected to be within child range of %v at order %d", nest, expected, order)
+			}
+		}
+	}
+}
+
+func TestUniquePixelHierarchy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		order  int
+		nest   NestPixel
+		parent UniquePixel
+	}{
+		{"Order 1: nest pixel 0", 1, 0, 4},
+		{"Order 1: nest pixel 47", 1, 47, 15},
+		{"Order 2: nest pixel 100", 2, 100, 41},
+		{"Max order: last nest pixel", MaxOrder(), NestPixel(New(NewHealpixOrder(MaxOrder())).Pixels() - 1),
+			NestPixel(New(NewHealpixOrder(MaxOrder()-1)).Pixels() - 1).ToUniquePixel(New(NewHealpixOrder(MaxOrder() - 1)))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uniq := tc.nest.ToUniquePixel(New(NewHealpixOrder(tc.order)))
+			if uniq.Order() != tc.order {
+				t.Errorf("Order expected %v, got %v instead", tc.order, uniq.Order())
+			}
+			if uniq.NestIndex() != tc.nest {
+				t.Errorf("Nest index expected %v, got %v instead", tc.nest, uniq.NestIndex())
+			}
+			if r := uniq.Parent(1); r != tc.parent {
+				t.Errorf("Parent expected %v, got %v instead", tc.parent, r)
+			}
+			if r := uniq.Ancestor(0); r.Order() != 0 || !uniq.IsDescendantOf(r) {
+				t.Errorf("Ancestor at order 0 expected to contain %v, got %v instead", uniq, r)
+			}
+			if uniq.Parent(1).IsDescendantOf(uniq) {
+				t.Errorf("Parent %v should not be a descendant of %v", uniq.Parent(1), uniq)
+			}
+			for _, sibling := range uniq.Siblings() {
+				if sibling.Parent(1) != tc.parent {
+					t.Errorf("Sibling %v expected parent %v, got %v instead", sibling, tc.parent, sibling.Parent(1))
+				}
+			}
+			if tc.order < MaxOrder() {
+				for _, child := range uniq.Children() {
+					if child.Order() != tc.order+1 || child.Parent(1) != uniq {
+						t.Errorf("Child %v expected to be at order %v with parent %v", child, tc.order+1, uniq)
+					}
+				}
+				if uniq.FirstChild(tc.order+1) != uniq.Children()[0] || uniq.LastChild(tc.order+1) != uniq.Children()[3] {
+					t.Errorf("First and last children expected to match children %v", uniq.Children())
+				}
+			}
+		})
+	}
+}
+
+func TestNestPixelParentPanics(t *testing.T) {
+	testCases := []struct {
+		name   string
+		levels int
+	}{
+		{"negative levels", -1},
+		{"past the maximum order", MaxOrder() + 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected %v levels to panic", tc.levels)
+				}
+			}()
+			NestPixel(100).Parent(tc.levels)
+		})
+	}
+}
+
+func TestUniquePixelParentPastOrderZeroPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected ascending past order 0 to panic")
+		}
+	}()
+	UniquePixel(4).Parent(1)
+}
@@ -0,0 +1,280 @@
+package healpix
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Identifies a point stored in a PointIndex, so it can later be moved or deleted.
+type PointHandle uint64
+
+// A point stored in a PointIndex: its position and the payload stored with it.
+type PointEntry[T any] struct {
+	handle   PointHandle
+	position Vector
+	value    T
+}
+
+// The handle returned when the point was inserted.
+func (e PointEntry[T]) Handle() PointHandle {
+	return e.handle
+}
+
+// The position of the point on the sphere.
+func (e PointEntry[T]) Position() SphereCoordinate {
+	return e.position.sphere()
+}
+
+// The unit vector of the position of the point.
+func (e PointEntry[T]) Vector() Vector {
+	return e.position
+}
+
+// The payload stored with the point.
+func (e PointEntry[T]) Value() T {
+	return e.value
+}
+
+// A point found by a nearest neighbor search, along with its angular distance from the search position.
+type PointNeighbor[T any] struct {
+	PointEntry[T]
+	distance float64
+}
+
+// The angular distance in radians from the search position to the point.
+func (n PointNeighbor[T]) Distance() float64 {
+	return n.distance
+}
+
+// An in-memory spatial index of points on the sphere, each carrying a payload of type T. Points are
+// bucketed by the nest pixel containing them at the order of the index, so searches only need to look at
+// the buckets of pixels near the search region. The order should be chosen so that a typical bucket holds
+// a handful of points: too coarse and searches scan many irrelevant points, too fine and nearest neighbor
+// searches in sparse regions walk many empty pixels. A PointIndex is not safe for concurrent modification.
+type PointIndex[T any] struct {
+	hp      Healpix
+	buckets map[NestPixel][]PointEntry[T]
+	pixels  map[PointHandle]NestPixel
+	next    PointHandle
+}
+
+// Create an empty index bucketing points by pixel of the given HEALPix map.
+func NewPointIndex[T any](hp Healpix) *PointIndex[T] {
+	return &PointIndex[T]{
+		hp:      hp,
+		buckets: map[NestPixel][]PointEntry[T]{},
+		pixels:  map[PointHandle]NestPixel{},
+	}
+}
+
+// The HEALPix map whose pixels the points are bucketed by.
+func (idx *PointIndex[T]) Healpix() Healpix {
+	return idx.hp
+}
+
+// The number of points in the index.
+func (idx *PointIndex[T]) Len() int {
+	return len(idx.pixels)
+}
+
+// Add a point at the given position to the index, returning a handle to later move or delete it.
+func (idx *PointIndex[T]) Insert(where Where, value T) PointHandle {
+	h := idx.next
+	idx.next++
+	idx.add(PointEntry[T]{h, toVector(idx.hp, where), value})
+	return h
+}
+
+// Remove the point with the given handle. Returns false if there is no such point.
+func (idx *PointIndex[T]) Delete(h PointHandle) bool {
+	_, ok := idx.remove(h)
+	return ok
+}
+
+// Change the position of the point with the given handle, keeping its payload. Returns false if there is
+// no such point.
+func (idx *PointIndex[T]) Move(h PointHandle, where Where) bool {
+	entry, ok := idx.remove(h)
+	if !ok {
+		return false
+	}
+	entry.position = toVector(idx.hp, where)
+	idx.add(entry)
+	return true
+}
+
+// Return the point with the given handle, and whether it exists.
+func (idx *PointIndex[T]) Get(h PointHandle) (PointEntry[T], bool) {
+	pixel, ok := idx.pixels[h]
+	if !ok {
+		return PointEntry[T]{}, false
+	}
+	for _, e := range idx.buckets[pixel] {
+		if e.handle == h {
+			return e, true
+		}
+	}
+	return PointEntry[T]{}, false
+}
+
+// Return every point within the given angular radius (in radians) of the center position, in no
+// particular order.
+func (idx *PointIndex[T]) QueryDisc(center Where, radius float64) []PointEntry[T] {
+	c := toVector(idx.hp, center)
+	result := []PointEntry[T]{}
+	visitDisc(idx.hp, c, radius, func(fp FacePixel, centerDistance float64, pixelRadius float64) {
+		// the whole pixel lies within the disc, so there is no need to check each point
+		inside := centerDistance+pixelRadius <= radius
+		for _, e := range idx.buckets[fp.ToNestPixel(idx.hp)] {
+			if inside || angleBetween(c, e.position) <= radius {
+				result = append(result, e)
+			}
+		}
+	})
+	return result
+}
+
+// Return every point inside the convex spherical polygon with great circle edges between the given
+// vertices, in no particular order. The same restrictions as QueryPolygon apply to the vertices.
+func (idx *PointIndex[T]) QueryPolygon(vertices []Where) []PointEntry[T] {
+	poly := newConvexPolygon(idx.hp, vertices)
+	result := []PointEntry[T]{}
+	visitDisc(idx.hp, poly.center, poly.radius, func(fp FacePixel, centerDistance float64, pixelRadius float64) {
+		for _, e := range idx.buckets[fp.ToNestPixel(idx.hp)] {
+			if poly.contains(e.position) {
+				result = append(result, e)
+			}
+		}
+	})
+	return result
+}
+
+// Return the k points nearest to the given position, ordered from nearest to farthest, with ties broken
+// by handle. Returns fewer than k points only if the index holds fewer than k points. The search expands
+// outward from the pixel containing the position through its neighbors, visiting pixels in order of the
+// smallest possible distance to any point they contain, and stops once no unvisited pixel can hold a point
+// nearer than the k-th nearest found so far.
+func (idx *PointIndex[T]) Nearest(where Where, k int) []PointNeighbor[T] {
+	if k <= 0 || idx.Len() == 0 {
+		return []PointNeighbor[T]{}
+	}
+	q := toVector(idx.hp, where)
+	start := q.ToFacePixel(idx.hp)
+
+	best := &neighborHeap[T]{}
+	pixels := &pixelHeap{{start, 0}}
+	seen := map[FacePixel]struct{}{start: {}}
+	scanned := 0
+	for pixels.Len() > 0 {
+		next := heap.Pop(pixels).(pixelBound)
+		if best.Len() == k && next.bound > (*best)[0].distance {
+			break
+		}
+		for _, e := range idx.buckets[next.pixel.ToNestPixel(idx.hp)] {
+			scanned++
+			candidate := PointNeighbor[T]{e, angleBetween(q, e.position)}
+			if best.Len() < k {
+				heap.Push(best, candidate)
+			} else if (*best)[0].farther(candidate) {
+				(*best)[0] = candidate
+				heap.Fix(best, 0)
+			}
+		}
+		if scanned == idx.Len() {
+			// every point has been seen, so the nearest are known
+			break
+		}
+		for d := South; d <= SouthWest; d++ {
+			neigh, ok := neighborFacePixel(idx.hp, next.pixel, d)
+			if !ok {
+				continue
+			}
+			if _, found := seen[neigh]; found {
+				continue
+			}
+			seen[neigh] = struct{}{}
+			center := neigh.ToSphereCoordinate(idx.hp).ToVector()
+			bound := max(0, angleBetween(q, center)-facePixelRadius(idx.hp, neigh))
+			heap.Push(pixels, pixelBound{neigh, bound})
+		}
+	}
+
+	result := []PointNeighbor[T](*best)
+	sort.Slice(result, func(i, j int) bool {
+		return result[j].farther(result[i])
+	})
+	return result
+}
+
+func (idx *PointIndex[T]) add(e PointEntry[T]) {
+	pixel := e.position.ToNestPixel(idx.hp)
+	idx.buckets[pixel] = append(idx.buckets[pixel], e)
+	idx.pixels[e.handle] = pixel
+}
+
+func (idx *PointIndex[T]) remove(h PointHandle) (PointEntry[T], bool) {
+	pixel, ok := idx.pixels[h]
+	if !ok {
+		return PointEntry[T]{}, false
+	}
+	delete(idx.pixels, h)
+	bucket := idx.buckets[pixel]
+	for i, e := range bucket {
+		if e.handle != h {
+			continue
+		}
+		last := len(bucket) - 1
+		bucket[i] = bucket[last]
+		bucket[last] = PointEntry[T]{}
+		if last == 0 {
+			delete(idx.buckets, pixel)
+		} else {
+			idx.buckets[pixel] = bucket[:last]
+		}
+		return e, true
+	}
+	return PointEntry[T]{}, false
+}
+
+// Whether n is farther from the search position than other, breaking ties by handle so that searches
+// are deterministic.
+func (n PointNeighbor[T]) farther(other PointNeighbor[T]) bool {
+	if n.distance != other.distance {
+		return n.distance > other.distance
+	}
+	return n.handle > other.handle
+}
+
+// A max-heap of the nearest points found so far, with the farthest on top.
+type neighborHeap[T any] []PointNeighbor[T]
+
+func (h neighborHeap[T]) Len() int           { return len(h) }
+func (h neighborHeap[T]) Less(i, j int) bool { return h[i].farther(h[j]) }
+func (h neighborHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap[T]) Push(x any)        { *h = append(*h, x.(PointNeighbor[T])) }
+func (h *neighborHeap[T]) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// A pixel waiting to be searched, with a lower bound on the distance to any point inside it.
+type pixelBound struct {
+	pixel FacePixel
+	bound float64
+}
+
+// A min-heap of the pixels waiting to be searched, with the nearest on top.
+type pixelHeap []pixelBound
+
+func (h pixelHeap) Len() int           { return len(h) }
+func (h pixelHeap) Less(i, j int) bool { return h[i].bound < h[j].bound }
+func (h pixelHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *pixelHeap) Push(x any)        { *h = append(*h, x.(pixelBound)) }
+func (h *pixelHeap) Pop() any {
+	old := *h
+	p := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return p
+}
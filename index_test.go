@@ -0,0 +1,124 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomPosition(rng *rand.Rand) SphereCoordinate {
+	return NewLatLonCoordinate(math.Asin(2*rng.Float64()-1), 2*math.Pi*rng.Float64())
+}
+
+func TestPointIndexInsertMoveDelete(t *testing.T) {
+	idx := NewPointIndex[string](New(NewHealpixOrder(4)))
+	a := idx.Insert(NewLatLonCoordinate(0.1, 0.2), "a")
+	b := idx.Insert(NewLatLonCoordinate(-0.5, 3.0), "b")
+	if idx.Len() != 2 {
+		t.Fatalf("Expected 2 points, got %v instead", idx.Len())
+	}
+	if e, ok := idx.Get(a); !ok || e.Value() != "a" {
+		t.Errorf("Expected point a, got %v,%v instead", e, ok)
+	}
+
+	if !idx.Move(a, NewLatLonCoordinate(-0.5, 3.0001)) {
+		t.Errorf("Expected to move point a")
+	}
+	near := idx.QueryDisc(NewLatLonCoordinate(-0.5, 3.0), 0.001)
+	if len(near) != 2 {
+		t.Errorf("Expected both points near the destination of the move, got %v instead", near)
+	}
+
+	if !idx.Delete(b) || idx.Delete(b) {
+		t.Errorf("Expected to delete point b exactly once")
+	}
+	if _, ok := idx.Get(b); ok || idx.Len() != 1 {
+		t.Errorf("Expected point b to be gone, leaving 1 point, got %v instead", idx.Len())
+	}
+}
+
+func TestPointIndexSearchesMatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(34))
+	idx := NewPointIndex[int](New(NewHealpixOrder(3)))
+	positions := []Vector{}
+	for i := 0; i < 2000; i++ {
+		pos := randomPosition(rng)
+		positions = append(positions, pos.ToVector())
+		idx.Insert(pos, i)
+	}
+
+	for trial := 0; trial < 20; trial++ {
+		center := randomPosition(rng)
+		c := center.ToVector()
+		radius := 0.3 * rng.Float64()
+
+		expected := 0
+		for _, p := range positions {
+			if angleBetween(c, p) <= radius {
+				expected++
+			}
+		}
+		found := idx.QueryDisc(center, radius)
+		if len(found) != expected {
+			t.Errorf("Disc of radius %v around %v expected %v points, got %v instead", radius, center, expected, len(found))
+		}
+		for _, e := range found {
+			if angleBetween(c, e.Vector()) > radius {
+				t.Errorf("Point %v is outside the disc", e.Value())
+			}
+		}
+
+		k := 1 + rng.Intn(20)
+		distances := []float64{}
+		for _, p := range positions {
+			distances = append(distances, angleBetween(c, p))
+		}
+		sort.Float64s(distances)
+		nearest := idx.Nearest(center, k)
+		if len(nearest) != k {
+			t.Fatalf("Expected %v nearest points, got %v instead", k, len(nearest))
+		}
+		for i, n := range nearest {
+			if n.Distance() != distances[i] {
+				t.Errorf("Nearest point %v expected distance %v, got %v instead", i, distances[i], n.Distance())
+			}
+		}
+	}
+}
+
+func TestPointIndexNearestFewerPoints(t *testing.T) {
+	idx := NewPointIndex[int](New(NewHealpixOrder(2)))
+	idx.Insert(NewLatLonCoordinate(1.2, 0), 1)
+	idx.Insert(NewLatLonCoordinate(-1.2, 3), 2)
+	nearest := idx.Nearest(NewLatLonCoordinate(1.1, 0), 5)
+	if len(nearest) != 2 || nearest[0].Value() != 1 || nearest[1].Value() != 2 {
+		t.Errorf("Expected both points nearest first, got %v instead", nearest)
+	}
+}
+
+func TestPointIndexQueryPolygon(t *testing.T) {
+	rng := rand.New(rand.NewSource(35))
+	idx := NewPointIndex[int](New(NewHealpixOrder(3)))
+	for i := 0; i < 2000; i++ {
+		idx.Insert(randomPosition(rng), i)
+	}
+	vertices := []Where{
+		NewLatLonCoordinate(0, 0),
+		NewLatLonCoordinate(0, 0.5),
+		NewLatLonCoordinate(0.5, 0.5),
+		NewLatLonCoordinate(0.5, 0),
+	}
+	poly := newConvexPolygon(idx.Healpix(), vertices)
+	expected := 0
+	for _, bucket := range idx.buckets {
+		for _, e := range bucket {
+			if poly.contains(e.Vector()) {
+				expected++
+			}
+		}
+	}
+	if found := idx.QueryPolygon(vertices); len(found) != expected || expected == 0 {
+		t.Errorf("Expected %v points in the polygon, got %v instead", expected, len(found))
+	}
+}
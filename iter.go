@@ -0,0 +1,140 @@
+package healpix
+
+import "iter"
+
+// Iterate over every pixel of the HEALPix map in increasing order of its nest index.
+func (o Healpix) AllNestPixels() iter.Seq[NestPixel] {
+	return func(yield func(NestPixel) bool) {
+		for p := NestPixel(0); p < NestPixel(o.Pixels()); p++ {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over every pixel of the HEALPix map in increasing order of its ring index, from the north pole to
+// the south pole.
+func (o Healpix) AllRingPixels() iter.Seq[RingPixel] {
+	return func(yield func(RingPixel) bool) {
+		for p := RingPixel(0); p < RingPixel(o.Pixels()); p++ {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the index of every pixel in the HEALPix map in the given scheme, in increasing order, along
+// with the position of the pixel center.
+func (o Healpix) AllPixelCenters(scheme HealpixScheme) iter.Seq2[uint, SphereCoordinate] {
+	return func(yield func(uint, SphereCoordinate) bool) {
+		if scheme == RingScheme {
+			// walking ring by ring avoids recomputing the latitude of every pixel
+			for ring := range o.AllRings() {
+				for p, center := range ring.PixelCenters() {
+					if !yield(uint(p), center) {
+						return
+					}
+				}
+			}
+			return
+		}
+		for p := NestPixel(0); p < NestPixel(o.Pixels()); p++ {
+			if !yield(uint(p), p.ToSphereCoordinate(o)) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over every ring of the HEALPix map, from the north pole to the south pole.
+func (o Healpix) AllRings() iter.Seq[Ring] {
+	return func(yield func(Ring) bool) {
+		for index := 0; index < o.Rings(); index++ {
+			if !yield(NewRing(o, index)) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the pixels of the ring in order of increasing longitude.
+func (r Ring) PixelsSeq() iter.Seq[RingPixel] {
+	return func(yield func(RingPixel) bool) {
+		first := RingPixel(r.FirstIndex())
+		for i := 0; i < r.Pixels(); i++ {
+			if !yield(first + RingPixel(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the pixels of the ring in order of increasing longitude, along with the position of each
+// pixel center.
+func (r Ring) PixelCenters() iter.Seq2[RingPixel, SphereCoordinate] {
+	return func(yield func(RingPixel, SphereCoordinate) bool) {
+		first := RingPixel(r.FirstIndex())
+		lat := r.Latitude()
+		colat := r.Colatitude()
+		for i := 0; i < r.Pixels(); i++ {
+			if !yield(first+RingPixel(i), SphereCoordinate{lat, colat, r.LongitudeOf(i)}) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the pixels of the face in the given HEALPix map, in increasing nest order.
+func (f Face) PixelsSeq(hp Healpix) iter.Seq[NestPixel] {
+	return func(yield func(NestPixel) bool) {
+		first := NestPixel(f.faceId * hp.FacePixels())
+		for i := 0; i < hp.FacePixels(); i++ {
+			if !yield(first + NestPixel(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the pixels of the face in the given HEALPix map, in increasing nest order, along with the
+// position of each pixel center.
+func (f Face) PixelCenters(hp Healpix) iter.Seq2[NestPixel, SphereCoordinate] {
+	return func(yield func(NestPixel, SphereCoordinate) bool) {
+		for p := range f.PixelsSeq(hp) {
+			if !yield(p, p.ToSphereCoordinate(hp)) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the descendants of this pixel of the given HEALPix map at the given finer order, in
+// increasing nest order. Descending to the order of the map itself yields only the pixel. Panics if
+// the order is coarser than the order of the map, or greater than MaxOrder().
+func (p NestPixel) Descendants(hp Healpix, order int) iter.Seq[NestPixel] {
+	first := p.FirstChild(hp, order)
+	last := p.LastChild(hp, order)
+	return func(yield func(NestPixel) bool) {
+		for d := first; d <= last; d++ {
+			if !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the descendants of this pixel of the given HEALPix map at the given finer order, in
+// increasing nest order, along with the position of each descendant's center.
+func (p NestPixel) DescendantCenters(hp Healpix, order int) iter.Seq2[NestPixel, SphereCoordinate] {
+	fine := New(NewHealpixOrder(order))
+	descendants := p.Descendants(hp, order)
+	return func(yield func(NestPixel, SphereCoordinate) bool) {
+		for d := range descendants {
+			if !yield(d, d.ToSphereCoordinate(fine)) {
+				return
+			}
+		}
+	}
+}
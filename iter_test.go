@@ -0,0 +1,141 @@
+package healpix
+
+import (
+	"testing"
+)
+
+func TestAllPixels(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	expected := NestPixel(0)
+	for p := range hp.AllNestPixels() {
+		if p != expected {
+			t.Fatalf("Expected nest pixel %v, got %v instead", expected, p)
+		}
+		expected++
+	}
+	if expected != NestPixel(hp.Pixels()) {
+		t.Errorf("Expected %v nest pixels, got %v instead", hp.Pixels(), expected)
+	}
+	expectedRing := RingPixel(0)
+	for p := range hp.AllRingPixels() {
+		if p != expectedRing {
+			t.Fatalf("Expected ring pixel %v, got %v instead", expectedRing, p)
+		}
+		expectedRing++
+	}
+	if expectedRing != RingPixel(hp.Pixels()) {
+		t.Errorf("Expected %v ring pixels, got %v instead", hp.Pixels(), expectedRing)
+	}
+
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		count := uint(0)
+		for p, center := range hp.AllPixelCenters(scheme) {
+			if p != count {
+				t.Fatalf("Expected pixel %v, got %v instead", count, p)
+			}
+			var where Where = NestPixel(p)
+			if scheme == RingScheme {
+				where = RingPixel(p)
+			}
+			if AngularDistance(hp, where, center) > 1e-12 {
+				t.Fatalf("Pixel %v center expected %v, got %v instead", p, where.ToSphereCoordinate(hp), center)
+			}
+			count++
+		}
+		if count != hp.Pixels() {
+			t.Errorf("Expected %v pixel centers, got %v instead", hp.Pixels(), count)
+		}
+	}
+}
+
+func TestAllRings(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	index := 0
+	next := RingPixel(0)
+	for ring := range hp.AllRings() {
+		if ring.Index() != index {
+			t.Fatalf("Expected ring %v, got %v instead", index, ring.Index())
+		}
+		for p := range ring.PixelsSeq() {
+			if p != next {
+				t.Fatalf("Ring %v: expected pixel %v, got %v instead", index, next, p)
+			}
+			next++
+		}
+		for p, center := range ring.PixelCenters() {
+			if center.Colatitude() != ring.Colatitude() || p.ToRingCoordinate(hp).Ring() != index {
+				t.Fatalf("Ring %v: pixel %v center %v not on ring", index, p, center)
+			}
+		}
+		index++
+	}
+	if index != hp.Rings() || next != RingPixel(hp.Pixels()) {
+		t.Errorf("Expected %v rings covering %v pixels, got %v covering %v instead", hp.Rings(), hp.Pixels(), index, next)
+	}
+}
+
+func TestFacePixelsSeq(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	for face := 0; face < 12; face++ {
+		expected := NestPixel(face * hp.FacePixels())
+		for p, center := range NewFace(face).PixelCenters(hp) {
+			if p != expected {
+				t.Fatalf("Face %v: expected pixel %v, got %v instead", face, expected, p)
+			}
+			if center.ToFacePixel(hp).Face() != face {
+				t.Fatalf("Face %v: pixel %v center lies in face %v", face, p, center.ToFacePixel(hp).Face())
+			}
+			expected++
+		}
+		if expected != NestPixel((face+1)*hp.FacePixels()) {
+			t.Errorf("Face %v: stopped at pixel %v", face, expected)
+		}
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	parent := NestPixel(13)
+	count := 0
+	for d, center := range parent.DescendantCenters(hp, 3) {
+		fine := New(NewHealpixOrder(3))
+		if !d.IsDescendantOf(fine, parent, 1) || center.ToNestPixel(fine) != d {
+			t.Fatalf("Pixel %v with center %v expected to descend from %v", d, center, parent)
+		}
+		count++
+	}
+	if count != 16 {
+		t.Errorf("Expected 16 descendants, got %v instead", count)
+	}
+	for d := range parent.Descendants(hp, 1) {
+		if d != parent {
+			t.Errorf("Expected descendants at the same order to be the pixel itself, got %v instead", d)
+		}
+	}
+}
+
+func TestIteratorsStopEarly(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	count := 0
+	for range hp.AllNestPixels() {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	for range hp.AllPixelCenters(RingScheme) {
+		count++
+		if count == 10 {
+			break
+		}
+	}
+	for range NestPixel(3).Descendants(hp, 4) {
+		count++
+		if count == 15 {
+			break
+		}
+	}
+	if count != 15 {
+		t.Errorf("Expected iteration to stop at each break, counted %v", count)
+	}
+}
@@ -0,0 +1,101 @@
+package healpix
+
+import (
+	"fmt"
+)
+
+// Return every pixel within k neighbor steps of the center pixel, including the center itself, in the
+// HEALPix index scheme desired. Steps follow the same pixel graph as NeighborsInto, so diagonal neighbors
+// are one step away and the rings are correct across face edges and around the polar vertices. Pixels are
+// ordered by their step distance from the center, as in KRingLayers. Panics if k is negative.
+func KRing(hp Healpix, center Where, k int, scheme HealpixScheme) []uint {
+	result := []uint{}
+	for _, layer := range KRingLayers(hp, center, k, scheme) {
+		result = append(result, layer...)
+	}
+	return result
+}
+
+// Return every pixel within k neighbor steps of the center pixel grouped by step distance, so that
+// layer i holds exactly the pixels i steps away from the center. Layer 0 holds the center alone.
+// Within a layer pixels are in a deterministic breadth first order. Panics if k is negative.
+func KRingLayers(hp Healpix, center Where, k int, scheme HealpixScheme) [][]uint {
+	if k < 0 {
+		panic(fmt.Sprintf("healpix: k-ring steps %d must not be negative", k))
+	}
+	layers := [][]uint{}
+	walkRings(hp, center.ToFacePixel(hp), k, func(step int, fp FacePixel) bool {
+		if step == len(layers) {
+			layers = append(layers, []uint{})
+		}
+		layers[step] = append(layers[step], fp.PixelId(hp, scheme))
+		return true
+	})
+	return layers
+}
+
+// Return the smallest number of neighbor steps needed to go from pixel a to pixel b. This performs a breadth
+// first search outward from a, so its time and memory grow with the square of the distance, and with Pixels()
+// for pixels on opposite sides of a fine map. Use GridDistanceWithin to bound the search.
+func GridDistance(hp Healpix, a Where, b Where) int {
+	return gridDistance(hp, a.ToFacePixel(hp), b.ToFacePixel(hp), -1)
+}
+
+// Return the smallest number of neighbor steps needed to go from pixel a to pixel b, or -1 if it takes more
+// than maxSteps. The breadth first search of GridDistance stops after maxSteps steps, so its time and memory
+// grow at most with the square of maxSteps, and pairs further apart than maxSteps steps can cover are rejected
+// from their angular distance without a search. Panics if maxSteps is negative.
+func GridDistanceWithin(hp Healpix, a Where, b Where, maxSteps int) int {
+	if maxSteps < 0 {
+		panic(fmt.Sprintf("healpix: grid distance steps %d must not be negative", maxSteps))
+	}
+	start, target := a.ToFacePixel(hp), b.ToFacePixel(hp)
+	// the centers of neighboring pixels are at most twice the largest pixel radius apart
+	if AngularDistance(hp, start, target) > 2*hp.MaxPixelRadius()*float64(maxSteps)*(1+1e-12) {
+		return -1
+	}
+	return gridDistance(hp, start, target, maxSteps)
+}
+
+// The step distance from start to target found by walkRings within k steps, or -1 if it is further.
+func gridDistance(hp Healpix, start FacePixel, target FacePixel, k int) int {
+	distance := -1
+	walkRings(hp, start, k, func(step int, fp FacePixel) bool {
+		if fp == target {
+			distance = step
+			return false
+		}
+		return true
+	})
+	return distance
+}
+
+// Visit the pixels around the start pixel in breadth first order over the neighbor graph, calling
+// visit with the step distance of each pixel exactly once. Stops after the pixels k steps away (or
+// never, if k < 0), when every pixel in the map has been visited, or as soon as visit returns false.
+func walkRings(hp Healpix, start FacePixel, k int, visit func(int, FacePixel) bool) {
+	seen := map[FacePixel]struct{}{start: {}}
+	frontier := []FacePixel{start}
+	for step := 0; len(frontier) > 0 && (k < 0 || step <= k); step++ {
+		next := []FacePixel{}
+		for _, fp := range frontier {
+			if !visit(step, fp) {
+				return
+			}
+			if step == k {
+				continue
+			}
+			for d := South; d <= SouthWest; d++ {
+				neigh, ok := neighborFacePixel(hp, fp, d)
+				if !ok {
+					continue
+				}
+				if _, found := seen[neigh]; !found {
+					seen[neigh] = struct{}{}
+					next = append(next, neigh)
+				}
+			}
+		}
+		frontier = next
+	}
+}
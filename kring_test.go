@@ -0,0 +1,140 @@
+package healpix
+
+import (
+	"testing"
+
+	"slices"
+)
+
+func TestKRingLayers(t *testing.T) {
+	testCases := []struct {
+		name   string
+		order  int
+		pixel  NestPixel
+		k      int
+		layers [][]uint
+	}{
+		{"Order 2: pixel 12 k=0", 2, 12, 0, [][]uint{{12}}},
+		{"Order 2: pixel 12 k=1", 2, 12, 1, [][]uint{{12}, {3, 6, 7, 13, 15, 14, 11, 9}}},
+		{"Order 1: pixel 19 k=1 skips the missing northern neighbor", 1, 19, 1, [][]uint{{19}, {16, 17, 0, 2, 13, 12, 18}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			layers := KRingLayers(New(NewHealpixOrder(tc.order)), tc.pixel, tc.k, NestScheme)
+			if len(layers) != len(tc.layers) {
+				t.Fatalf("Expected %v layers, got %v instead", len(tc.layers), layers)
+			}
+			for i := range layers {
+				if !slices.Equal(layers[i], tc.layers[i]) {
+					t.Errorf("Layer %v expected %v, got %v instead", i, tc.layers[i], layers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKRingInsideFace(t *testing.T) {
+	hp := New(NewHealpixOrder(6))
+	center := NewFacePixel(5, 30, 30)
+	for k := 0; k < 10; k++ {
+		ring := KRing(hp, center, k, NestScheme)
+		if len(ring) != (2*k+1)*(2*k+1) {
+			t.Errorf("k=%v expected %v pixels, got %v instead", k, (2*k+1)*(2*k+1), len(ring))
+		}
+	}
+}
+
+func TestKRingCoversSphere(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		ring := KRing(hp, NestPixel(0), 4*hp.FaceSidePixels(), scheme)
+		if uint(len(ring)) != hp.Pixels() {
+			t.Errorf("Expected the whole sphere of %v pixels, got %v instead", hp.Pixels(), len(ring))
+		}
+		sorted := slices.Clone(ring)
+		slices.Sort(sorted)
+		if len(slices.Compact(sorted)) != len(ring) {
+			t.Errorf("Expected no duplicate pixels in %v", ring)
+		}
+	}
+}
+
+func TestGridDistance(t *testing.T) {
+	for order := 0; order <= 2; order++ {
+		hp := New(NewHealpixOrder(order))
+		for a := NestPixel(0); a < NestPixel(hp.Pixels()); a += 7 {
+			layers := KRingLayers(hp, a, 4*hp.FaceSidePixels(), NestScheme)
+			for step, layer := range layers {
+				for _, b := range layer {
+					if d := GridDistance(hp, a, NestPixel(b)); d != step {
+						t.Fatalf("Order %v: distance from %v to %v expected %v, got %v instead", order, a, b, step, d)
+					}
+					if d := GridDistanceWithin(hp, a, NestPixel(b), step); d != step {
+						t.Fatalf("Order %v: distance from %v to %v within %v steps expected %v, got %v instead", order, a, b, step, step, d)
+					}
+					if step > 0 && GridDistanceWithin(hp, a, NestPixel(b), step-1) != -1 {
+						t.Fatalf("Order %v: distance from %v to %v within %v steps expected -1", order, a, b, step-1)
+					}
+				}
+			}
+			var neighbors [8]int64
+			NeighborsInto(hp, a, NestScheme, &neighbors)
+			for _, n := range neighbors {
+				if n >= 0 && GridDistanceWithin(hp, NestPixel(n), a, 1) != 1 {
+					t.Fatalf("Order %v: distance from neighbor %v to %v expected 1", order, n, a)
+				}
+			}
+		}
+	}
+}
+
+func TestGridDistanceWithinOnFineMaps(t *testing.T) {
+	hp := New(NewHealpixOrder(20))
+	a := NewLatLonCoordinate(0.3, 1)
+	testCases := []struct {
+		name     string
+		b        Where
+		maxSteps int
+		expected int
+	}{
+		{"same pixel", a, 0, 0},
+		{"far side of the sphere", NewLatLonCoordinate(-0.3, 4), 100, -1},
+		{"just beyond the bound", NewLatLonCoordinate(0.3, 1+60*hp.AngularResolution()), 10, -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if d := GridDistanceWithin(hp, a, tc.b, tc.maxSteps); d != tc.expected {
+				t.Errorf("Expected distance %v, got %v instead", tc.expected, d)
+			}
+		})
+	}
+	// a nearby pixel is found by a search bounded by its distance
+	b := a.ToFacePixel(hp)
+	b = NewFacePixel(b.Face(), b.X()+30, b.Y()+12)
+	if d := GridDistanceWithin(hp, a, b, 40); d != 30 {
+		t.Errorf("Expected distance 30, got %v instead", d)
+	}
+}
+
+func TestKRingNegativeStepsPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	testCases := []struct {
+		name string
+		call func()
+	}{
+		{"KRing", func() { KRing(hp, NestPixel(5), -1, NestScheme) }},
+		{"KRingLayers", func() { KRingLayers(hp, NestPixel(5), -1, RingScheme) }},
+		{"GridDistanceWithin", func() { GridDistanceWithin(hp, NestPixel(5), NestPixel(6), -1) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected negative steps to panic")
+				}
+			}()
+			tc.call()
+		})
+	}
+}
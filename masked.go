@@ -0,0 +1,261 @@
+package healpix
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// The value marking pixels without data in HEALPix FITS files and healpy.
+const Unseen = -1.6375e30
+
+// Whether the value marks a pixel without data. Like healpy, values within a relative tolerance of 1e-5 of
+// Unseen count, so that maps stored in single precision are recognized too.
+func IsUnseen(v float64) bool {
+	return math.Abs(v-Unseen) <= 1e-5*math.Abs(Unseen)
+}
+
+// Whether the value is missing, either Unseen or NaN.
+func IsMissing(v float64) bool {
+	return math.IsNaN(v) || IsUnseen(v)
+}
+
+// A map of values along with which of its pixels hold valid data. Arithmetic and reductions skip the invalid
+// pixels, and the invalid pixels of derived maps hold Unseen. The pixels of a single order map all have the
+// same area; the cells of a multi-order map are weighted by their areas in reductions.
+type MaskedMap struct {
+	values []float64
+	valid  []bool
+	areas  []float64 // nil when all the pixels have the same area
+}
+
+// Create a masked map of the values, where the pixels holding Unseen or NaN are invalid. The map keeps the
+// values slice rather than copying it.
+func NewMaskedMap(values []float64) MaskedMap {
+	valid := make([]bool, len(values))
+	for p, v := range values {
+		valid[p] = !IsMissing(v)
+	}
+	return MaskedMap{values, valid, nil}
+}
+
+// Create a masked map of the values with an explicit validity bitmap. The values of invalid pixels are ignored,
+// whatever they are. The map keeps both slices rather than copying them. Panics if the slices differ in length.
+func NewMaskedMapWithValidity(values []float64, valid []bool) MaskedMap {
+	if len(values) != len(valid) {
+		panic(fmt.Sprintf("healpix: masked map has %d values but %d validity flags", len(values), len(valid)))
+	}
+	return MaskedMap{values, valid, nil}
+}
+
+// Create a masked map of the values of cells of mixed orders, where the pixels holding Unseen or NaN are
+// invalid. Each value is weighted by the area of its cell in reductions. The map keeps the values slice rather
+// than copying it. Panics if the slices differ in length.
+func NewMultiOrderMap(pixels []UniquePixel, values []float64) MaskedMap {
+	if len(values) != len(pixels) {
+		panic(fmt.Sprintf("healpix: multi-order map has %d values but %d pixels", len(values), len(pixels)))
+	}
+	m := NewMaskedMap(values)
+	m.areas = make([]float64, len(pixels))
+	for i, p := range pixels {
+		m.areas[i] = p.base().PixelArea()
+	}
+	return m
+}
+
+// The number of pixels of the map, valid or not.
+func (m MaskedMap) Len() int {
+	return len(m.values)
+}
+
+// The values of the map, including the ignored values of the invalid pixels. Changes to the returned slice
+// change the map.
+func (m MaskedMap) Values() []float64 {
+	return m.values
+}
+
+// Whether each pixel of the map holds valid data. Changes to the returned slice change the map.
+func (m MaskedMap) Valid() []bool {
+	return m.valid
+}
+
+// Whether the pixel at the given position holds valid data.
+func (m MaskedMap) IsValid(p int) bool {
+	return m.valid[p]
+}
+
+// The number of pixels holding valid data.
+func (m MaskedMap) ValidCount() int {
+	count := 0
+	for _, v := range m.valid {
+		if v {
+			count++
+		}
+	}
+	return count
+}
+
+// The area in steradians of each pixel of a multi-order map, or nil for a map whose pixels all have the same
+// area.
+func (m MaskedMap) Areas() []float64 {
+	return m.areas
+}
+
+// Return a copy of the values of the map with the invalid pixels set to fill, e.g. Unseen to write the map in
+// the HEALPix convention, or 0 to feed it to code without a notion of missing data.
+func (m MaskedMap) Filled(fill float64) []float64 {
+	filled := slices.Clone(m.values)
+	for p, v := range m.valid {
+		if !v {
+			filled[p] = fill
+		}
+	}
+	return filled
+}
+
+// Return the map of f applied to the values of this map and the other map, pixel by pixel. A pixel of the
+// result is valid where it is valid in both maps and the result is finite, so e.g. dividing by zero leaves
+// the pixel invalid. The result keeps the areas of this map. Panics if the maps differ in length.
+func (m MaskedMap) Combine(other MaskedMap, f func(a float64, b float64) float64) MaskedMap {
+	if len(m.values) != len(other.values) {
+		panic(fmt.Sprintf("healpix: cannot combine masked maps of %d and %d pixels", len(m.values), len(other.values)))
+	}
+	return m.derive(func(p int) (float64, bool) {
+		return f(m.values[p], other.values[p]), m.valid[p] && other.valid[p]
+	})
+}
+
+// Return the map of f applied to the values of this map. A pixel of the result is valid where it is valid in
+// this map and the result is finite.
+func (m MaskedMap) Apply(f func(v float64) float64) MaskedMap {
+	return m.derive(func(p int) (float64, bool) {
+		return f(m.values[p]), m.valid[p]
+	})
+}
+
+// Return a map with the area of this one and the value and validity of each pixel given by value, with invalid
+// or non-finite values replaced by Unseen.
+func (m MaskedMap) derive(value func(p int) (float64, bool)) MaskedMap {
+	values := make([]float64, len(m.values))
+	valid := make([]bool, len(m.values))
+	for p := range values {
+		if v, ok := value(p); ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			values[p], valid[p] = v, true
+		} else {
+			values[p] = Unseen
+		}
+	}
+	return MaskedMap{values, valid, m.areas}
+}
+
+// Return the pixel by pixel sum of this map and the other. See Combine.
+func (m MaskedMap) Add(other MaskedMap) MaskedMap {
+	return m.Combine(other, func(a float64, b float64) float64 { return a + b })
+}
+
+// Return the pixel by pixel difference of this map and the other. See Combine.
+func (m MaskedMap) Subtract(other MaskedMap) MaskedMap {
+	return m.Combine(other, func(a float64, b float64) float64 { return a - b })
+}
+
+// Return the pixel by pixel product of this map and the other. See Combine.
+func (m MaskedMap) Multiply(other MaskedMap) MaskedMap {
+	return m.Combine(other, func(a float64, b float64) float64 { return a * b })
+}
+
+// Return the pixel by pixel quotient of this map and the other, which is invalid where the other is 0. See
+// Combine.
+func (m MaskedMap) Divide(other MaskedMap) MaskedMap {
+	return m.Combine(other, func(a float64, b float64) float64 { return a / b })
+}
+
+// A valid value of a map with its weight in reductions.
+type weightedValue struct {
+	value  float64
+	weight float64
+}
+
+func (m MaskedMap) weightedValues() []weightedValue {
+	values := make([]weightedValue, 0, len(m.values))
+	for p, v := range m.valid {
+		if v {
+			weight := 1.0
+			if m.areas != nil {
+				weight = m.areas[p]
+			}
+			values = append(values, weightedValue{m.values[p], weight})
+		}
+	}
+	return values
+}
+
+// The mean of the valid values, weighted by area for a multi-order map. NaN if no pixel is valid.
+func (m MaskedMap) Mean() float64 {
+	sum, weight := 0.0, 0.0
+	for _, wv := range m.weightedValues() {
+		sum += wv.weight * wv.value
+		weight += wv.weight
+	}
+	return sum / weight
+}
+
+// The population standard deviation of the valid values, weighted by area for a multi-order map. NaN if no
+// pixel is valid.
+func (m MaskedMap) Std() float64 {
+	values := m.weightedValues()
+	mean := m.Mean()
+	sum, weight := 0.0, 0.0
+	for _, wv := range values {
+		sum += wv.weight * (wv.value - mean) * (wv.value - mean)
+		weight += wv.weight
+	}
+	return math.Sqrt(sum / weight)
+}
+
+// The median of the valid values, weighted by area for a multi-order map. NaN if no pixel is valid.
+func (m MaskedMap) Median() float64 {
+	return m.Percentile(50)
+}
+
+// The q-th percentile of the valid values, for q from 0 to 100, interpolating linearly between the sorted
+// values like numpy's default method. The percentiles of a multi-order map are those of the map regridded to
+// its finest order, where each cell counts once for each of the finest pixels it covers. NaN if no pixel is
+// valid. Panics if q is outside 0 - 100.
+func (m MaskedMap) Percentile(q float64) float64 {
+	if !(q >= 0 && q <= 100) {
+		panic(fmt.Sprintf("healpix: percentile %v must be between 0 and 100", q))
+	}
+	values := m.weightedValues()
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	slices.SortFunc(values, func(a weightedValue, b weightedValue) int { return cmp.Compare(a.value, b.value) })
+	// the areas of the cells of a multi-order map are the area of the finest pixels times a power of 4
+	finest := values[0].weight
+	for _, wv := range values {
+		finest = math.Min(finest, wv.weight)
+	}
+	total := 0.0
+	for i := range values {
+		values[i].weight = math.Round(values[i].weight / finest)
+		total += values[i].weight
+	}
+	// the value at a position of the sorted values repeated by their counts
+	at := func(position float64) float64 {
+		for _, wv := range values {
+			if position < wv.weight {
+				return wv.value
+			}
+			position -= wv.weight
+		}
+		return values[len(values)-1].value
+	}
+	position := q / 100 * (total - 1)
+	below := math.Floor(position)
+	low := at(below)
+	if below == position {
+		return low
+	}
+	return low + (position-below)*(at(below+1)-low)
+}
@@ -0,0 +1,163 @@
+package healpix
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestIsMissing(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   float64
+		unseen  bool
+		missing bool
+	}{
+		{"unseen", Unseen, true, true},
+		{"single precision unseen", float64(float32(Unseen)), true, true},
+		{"NaN", math.NaN(), false, true},
+		{"zero", 0, false, false},
+		{"large negative", -1.6e30, false, false},
+		{"infinity", math.Inf(-1), false, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if unseen := IsUnseen(tc.value); unseen != tc.unseen {
+				t.Errorf("expected IsUnseen %v, got %v", tc.unseen, unseen)
+			}
+			if missing := IsMissing(tc.value); missing != tc.missing {
+				t.Errorf("expected IsMissing %v, got %v", tc.missing, missing)
+			}
+		})
+	}
+}
+
+func TestMaskedMapArithmetic(t *testing.T) {
+	a := NewMaskedMap([]float64{1, 2, Unseen, 4, 5, -1})
+	b := NewMaskedMapWithValidity([]float64{2, 0, 1, math.NaN(), 7, 3}, []bool{true, true, true, true, false, true})
+	if count := a.ValidCount(); count != 5 {
+		t.Errorf("expected 5 valid pixels, got %d", count)
+	}
+	testCases := []struct {
+		name     string
+		result   MaskedMap
+		expected []float64
+	}{
+		{"add", a.Add(b), []float64{3, 2, Unseen, math.NaN(), Unseen, 2}},
+		{"subtract", a.Subtract(b), []float64{-1, 2, Unseen, math.NaN(), Unseen, -4}},
+		{"multiply", a.Multiply(b), []float64{2, 0, Unseen, math.NaN(), Unseen, -3}},
+		{"divide", a.Divide(b), []float64{0.5, Unseen, Unseen, math.NaN(), Unseen, -1.0 / 3}},
+		{"apply", a.Apply(math.Sqrt), []float64{1, math.Sqrt2, Unseen, 2, math.Sqrt(5), Unseen}},
+		{"combine", a.Combine(b, math.Max), []float64{2, 2, Unseen, math.NaN(), Unseen, 3}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.result.Len() != len(tc.expected) {
+				t.Fatalf("expected %d pixels, got %d", len(tc.expected), tc.result.Len())
+			}
+			for p, expected := range tc.expected {
+				// a NaN marks a pixel valid in the validity bitmap whose value is NaN, which stays invalid
+				if math.IsNaN(expected) {
+					expected = Unseen
+				}
+				valid := expected != Unseen
+				if tc.result.IsValid(p) != valid {
+					t.Errorf("pixel %d: expected valid %v", p, valid)
+				}
+				if value := tc.result.Values()[p]; value != expected && !withinTolerance(value, expected, 1e-15) {
+					t.Errorf("pixel %d: expected %v, got %v", p, expected, value)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskedMapFilled(t *testing.T) {
+	m := NewMaskedMapWithValidity([]float64{1, 2, 3}, []bool{true, false, true})
+	if filled := m.Filled(Unseen); !slices.Equal(filled, []float64{1, Unseen, 3}) {
+		t.Errorf("unexpected filled map %v", filled)
+	}
+	if filled := m.Filled(0); !slices.Equal(filled, []float64{1, 0, 3}) {
+		t.Errorf("unexpected filled map %v", filled)
+	}
+	if m.Values()[1] != 2 {
+		t.Errorf("expected filling to leave the map unchanged")
+	}
+}
+
+func TestMaskedMapReductions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		m      MaskedMap
+		mean   float64
+		std    float64
+		median float64
+		q25    float64
+		q90    float64
+	}{
+		// the percentiles match numpy's default linear method
+		{"single order", NewMaskedMap([]float64{4, Unseen, 1, 3, math.NaN(), 2}), 2.5, math.Sqrt(1.25), 2.5, 1.75, 3.7},
+		{"odd count", NewMaskedMap([]float64{5, 1, 3}), 3, math.Sqrt(8.0 / 3), 3, 2, 4.6},
+		{"single value", NewMaskedMap([]float64{7, Unseen}), 7, 0, 7, 7, 7},
+		// cells of equal area weigh the same as in a single order map
+		{"multi-order equal areas", NewMultiOrderMap(
+			[]UniquePixel{NewUniquePixel(2, 3), NewUniquePixel(2, 100), NewUniquePixel(2, 7), NewUniquePixel(2, 42)},
+			[]float64{4, 1, 3, 2}), 2.5, math.Sqrt(1.25), 2.5, 1.75, 3.7},
+		// an order 0 cell has the area of four order 1 cells
+		{"multi-order", NewMultiOrderMap(
+			[]UniquePixel{NewUniquePixel(0, 0), NewUniquePixel(1, 4), NewUniquePixel(1, 5)},
+			[]float64{1, 7, Unseen}), 2.2, 2.4, 1, 1, 4.6},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if mean := tc.m.Mean(); !withinTolerance(mean, tc.mean, 1e-12) {
+				t.Errorf("expected mean %v, got %v", tc.mean, mean)
+			}
+			if std := tc.m.Std(); std != tc.std && !withinTolerance(std, tc.std, 1e-12) {
+				t.Errorf("expected std %v, got %v", tc.std, std)
+			}
+			if median := tc.m.Median(); !withinTolerance(median, tc.median, 1e-12) {
+				t.Errorf("expected median %v, got %v", tc.median, median)
+			}
+			if q := tc.m.Percentile(25); !withinTolerance(q, tc.q25, 1e-12) {
+				t.Errorf("expected 25th percentile %v, got %v", tc.q25, q)
+			}
+			if q := tc.m.Percentile(90); !withinTolerance(q, tc.q90, 1e-12) {
+				t.Errorf("expected 90th percentile %v, got %v", tc.q90, q)
+			}
+		})
+	}
+}
+
+func TestMaskedMapReductionsEmpty(t *testing.T) {
+	m := NewMaskedMap([]float64{Unseen, math.NaN()})
+	for name, value := range map[string]float64{"mean": m.Mean(), "std": m.Std(), "median": m.Median(), "percentile": m.Percentile(10)} {
+		if !math.IsNaN(value) {
+			t.Errorf("expected NaN %s of an empty map, got %v", name, value)
+		}
+	}
+}
+
+func TestMaskedMapPanics(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func()
+	}{
+		{"validity length", func() { NewMaskedMapWithValidity([]float64{1, 2}, []bool{true}) }},
+		{"multi-order length", func() { NewMultiOrderMap([]UniquePixel{NewUniquePixel(0, 0)}, []float64{1, 2}) }},
+		{"combine length", func() { NewMaskedMap([]float64{1, 2}).Add(NewMaskedMap([]float64{1})) }},
+		{"percentile below", func() { NewMaskedMap([]float64{1}).Percentile(-1) }},
+		{"percentile above", func() { NewMaskedMap([]float64{1}).Percentile(101) }},
+		{"percentile NaN", func() { NewMaskedMap([]float64{1}).Percentile(math.NaN()) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tc.call()
+		})
+	}
+}
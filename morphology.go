@@ -0,0 +1,304 @@
+package healpix
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+)
+
+// The neighborhood by which the morphological operations grow or shrink a set of pixels: either a number of
+// steps between neighboring pixels, or an angular radius between pixel centers.
+type StructuringElement struct {
+	steps        int
+	connectivity Connectivity
+	radius       float64
+	byRadius     bool
+}
+
+// Create a structuring element reaching the pixels up to the given number of steps away, where each step
+// moves to a neighbor connected by the given connectivity. Panics if steps is negative or the connectivity is
+// invalid.
+func NewStepElement(steps int, connectivity Connectivity) StructuringElement {
+	if steps < 0 {
+		panic(fmt.Sprintf("healpix: structuring element steps %d must not be negative", steps))
+	}
+	if connectivity != EdgeConnectivity && connectivity != VertexConnectivity {
+		panic(fmt.Sprintf("healpix: invalid connectivity %d, expected 4 or 8", connectivity))
+	}
+	return StructuringElement{steps: steps, connectivity: connectivity}
+}
+
+// Create a structuring element reaching the pixels whose centers are within the given angular radius (in
+// radians) of the pixel center. Divide a distance by the radius of the sphere to buffer by a length, e.g. 50
+// km on the Earth. Panics if the radius is negative.
+func NewRadiusElement(radius float64) StructuringElement {
+	if radius < 0 {
+		panic(fmt.Sprintf("healpix: structuring element radius %v must not be negative", radius))
+	}
+	return StructuringElement{radius: radius, byRadius: true}
+}
+
+// Return the pixels of the map outside the set that are reached by the element from a pixel in the set.
+// Only the pixels of the set on its boundary, which have a neighbor outside the set, are needed as sources,
+// as the nearest pixel of the set to any pixel outside it is on the boundary.
+func (e StructuringElement) reach(hp Healpix, scheme HealpixScheme, member func(uint) bool, boundary []uint) []uint {
+	if e.byRadius {
+		reached := []uint{}
+		for p := range nearestDistances(hp, scheme, member, boundary, e.radius) {
+			reached = append(reached, p)
+		}
+		return reached
+	}
+	seen := map[uint]struct{}{}
+	frontier := boundary
+	reached := []uint{}
+	for step := 0; step < e.steps && len(frontier) > 0; step++ {
+		next := []uint{}
+		for _, p := range frontier {
+			e.connectivity.neighbors(hp, schemePixel(hp, p, scheme), func(neigh FacePixel) {
+				id := neigh.PixelId(hp, scheme)
+				if _, found := seen[id]; found || member(id) {
+					return
+				}
+				seen[id] = struct{}{}
+				next = append(next, id)
+			})
+		}
+		reached = append(reached, next...)
+		frontier = next
+	}
+	return reached
+}
+
+// A candidate source of the nearest pixel of the set to a pixel, waiting to be propagated to its neighbors.
+type reachState struct {
+	distance float64
+	pixel    uint
+	source   uint
+}
+
+type reachHeap []reachState
+
+func (h reachHeap) Len() int           { return len(h) }
+func (h reachHeap) Less(i, j int) bool { return h[i].distance < h[j].distance }
+func (h reachHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *reachHeap) Push(x any)        { *h = append(*h, x.(reachState)) }
+func (h *reachHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Return the distance from the center of each pixel outside the set to the center of the nearest boundary
+// pixel of the set, for the pixels where it is at most radius. Sources are propagated outward from the
+// boundary through neighboring pixels in order of distance, and each pixel passes on every source that is
+// within twice the maximum pixel radius of the nearest source found for it. The pixels along the great circle
+// from a pixel to its nearest source have centers within the maximum pixel radius of that great circle, so
+// with this slack the nearest source always reaches the pixel, and the distances are exact without searching
+// the whole disc around every pixel. The number of sources each pixel passes on grows with the square root
+// of its distance from the boundary, measured in pixels.
+func nearestDistances(hp Healpix, scheme HealpixScheme, member func(uint) bool, boundary []uint, radius float64) map[uint]float64 {
+	pixelRadius := hp.MaxPixelRadius()
+	slack := 2 * pixelRadius
+	limit := radius + pixelRadius
+	centers := map[uint]Vector{}
+	center := func(p uint) Vector {
+		c, found := centers[p]
+		if !found {
+			c = schemePixel(hp, p, scheme).ToSphereCoordinate(hp).ToVector()
+			centers[p] = c
+		}
+		return c
+	}
+	type pixelSource struct{ pixel, source uint }
+	best := map[uint]float64{}
+	visited := map[pixelSource]struct{}{}
+	queue := &reachHeap{}
+	for _, s := range boundary {
+		best[s] = 0
+		visited[pixelSource{s, s}] = struct{}{}
+		heap.Push(queue, reachState{0, s, s})
+	}
+	for queue.Len() > 0 {
+		state := heap.Pop(queue).(reachState)
+		if state.distance > best[state.pixel]+slack {
+			continue
+		}
+		source := center(state.source)
+		VertexConnectivity.neighbors(hp, schemePixel(hp, state.pixel, scheme), func(neigh FacePixel) {
+			id := neigh.PixelId(hp, scheme)
+			key := pixelSource{id, state.source}
+			if _, found := visited[key]; found {
+				return
+			}
+			distance := angleBetween(source, center(id))
+			if distance > limit {
+				return
+			}
+			b, found := best[id]
+			if !found {
+				b = distance
+				if member(id) {
+					b = 0
+				}
+				best[id] = b
+			}
+			if distance > b+slack {
+				return
+			}
+			best[id] = min(b, distance)
+			visited[key] = struct{}{}
+			heap.Push(queue, reachState{distance, id, state.source})
+		})
+	}
+	distances := map[uint]float64{}
+	for p, distance := range best {
+		if distance <= radius && !member(p) {
+			distances[p] = distance
+		}
+	}
+	return distances
+}
+
+// The pixels of the set with at least one neighbor outside it, which are the only ones the morphological
+// operations need to start from.
+func setBoundary(hp Healpix, scheme HealpixScheme, member func(uint) bool, pixels func(yield func(uint) bool)) []uint {
+	boundary := []uint{}
+	for p := range pixels {
+		outside := false
+		VertexConnectivity.neighbors(hp, schemePixel(hp, p, scheme), func(neigh FacePixel) {
+			outside = outside || !member(neigh.PixelId(hp, scheme))
+		})
+		if outside {
+			boundary = append(boundary, p)
+		}
+	}
+	return boundary
+}
+
+func checkMaskSize(hp Healpix, mask []bool) {
+	if uint(len(mask)) != hp.Pixels() {
+		panic(fmt.Sprintf("healpix: mask has %d values but the HEALPix map has %d pixels", len(mask), hp.Pixels()))
+	}
+}
+
+// Return the set pixels of the mask, or the unset ones if set is false.
+func maskPixels(mask []bool, set bool) func(yield func(uint) bool) {
+	return func(yield func(uint) bool) {
+		for p, v := range mask {
+			if v == set && !yield(uint(p)) {
+				return
+			}
+		}
+	}
+}
+
+// Return the dilation of the mask, indexed in the given scheme, by the structuring element: the mask with
+// every pixel reached by the element from a set pixel also set. Panics if the mask does not have one value
+// per pixel.
+func Dilate(hp Healpix, mask []bool, scheme HealpixScheme, element StructuringElement) []bool {
+	checkMaskSize(hp, mask)
+	member := func(p uint) bool { return mask[p] }
+	result := slices.Clone(mask)
+	for _, p := range element.reach(hp, scheme, member, setBoundary(hp, scheme, member, maskPixels(mask, true))) {
+		result[p] = true
+	}
+	return result
+}
+
+// Return the erosion of the mask, indexed in the given scheme, by the structuring element: the mask with
+// every set pixel reached by the element from an unset pixel cleared. Panics if the mask does not have one
+// value per pixel.
+func Erode(hp Healpix, mask []bool, scheme HealpixScheme, element StructuringElement) []bool {
+	checkMaskSize(hp, mask)
+	unset := func(p uint) bool { return !mask[p] }
+	result := slices.Clone(mask)
+	for _, p := range element.reach(hp, scheme, unset, setBoundary(hp, scheme, unset, maskPixels(mask, false))) {
+		result[p] = false
+	}
+	return result
+}
+
+// Return the opening of the mask by the structuring element, which is the dilation of its erosion. Opening
+// removes the parts of the mask too small or thin to hold the element. Panics if the mask does not have one
+// value per pixel.
+func Open(hp Healpix, mask []bool, scheme HealpixScheme, element StructuringElement) []bool {
+	return Dilate(hp, Erode(hp, mask, scheme, element), scheme, element)
+}
+
+// Return the closing of the mask by the structuring element, which is the erosion of its dilation. Closing
+// fills the gaps and holes of the mask too small or thin to hold the element. Panics if the mask does not
+// have one value per pixel.
+func Close(hp Healpix, mask []bool, scheme HealpixScheme, element StructuringElement) []bool {
+	return Erode(hp, Dilate(hp, mask, scheme, element), scheme, element)
+}
+
+// Return the pixel ids of the list as a set along with its sorted pixels without duplicates.
+func pixelSet(pixels []uint) (map[uint]struct{}, []uint) {
+	set := make(map[uint]struct{}, len(pixels))
+	for _, p := range pixels {
+		set[p] = struct{}{}
+	}
+	sorted := make([]uint, 0, len(set))
+	for p := range set {
+		sorted = append(sorted, p)
+	}
+	slices.Sort(sorted)
+	return set, sorted
+}
+
+// Return the dilation of the set of pixels, in the given scheme, by the structuring element, in increasing
+// order. Unlike Dilate this only visits the pixels near the set, so it suits small sets on fine maps.
+func DilatePixels(hp Healpix, pixels []uint, scheme HealpixScheme, element StructuringElement) []uint {
+	set, sorted := pixelSet(pixels)
+	member := func(p uint) bool {
+		_, found := set[p]
+		return found
+	}
+	result := append(sorted, element.reach(hp, scheme, member, setBoundary(hp, scheme, member, slices.Values(sorted)))...)
+	slices.Sort(result)
+	return result
+}
+
+// Return the erosion of the set of pixels, in the given scheme, by the structuring element, in increasing
+// order. Unlike Erode this only visits the pixels near the set, so it suits small sets on fine maps.
+func ErodePixels(hp Healpix, pixels []uint, scheme HealpixScheme, element StructuringElement) []uint {
+	set, sorted := pixelSet(pixels)
+	outside := func(p uint) bool {
+		_, found := set[p]
+		return !found
+	}
+	// the unset pixels next to the set are the boundary of its complement
+	boundary := map[uint]struct{}{}
+	for _, p := range sorted {
+		VertexConnectivity.neighbors(hp, schemePixel(hp, p, scheme), func(neigh FacePixel) {
+			if id := neigh.PixelId(hp, scheme); outside(id) {
+				boundary[id] = struct{}{}
+			}
+		})
+	}
+	sources := make([]uint, 0, len(boundary))
+	for p := range boundary {
+		sources = append(sources, p)
+	}
+	for _, p := range element.reach(hp, scheme, outside, sources) {
+		delete(set, p)
+	}
+	return slices.DeleteFunc(sorted, func(p uint) bool {
+		_, found := set[p]
+		return !found
+	})
+}
+
+// Return the opening of the set of pixels, in the given scheme, by the structuring element, in increasing
+// order.
+func OpenPixels(hp Healpix, pixels []uint, scheme HealpixScheme, element StructuringElement) []uint {
+	return DilatePixels(hp, ErodePixels(hp, pixels, scheme, element), scheme, element)
+}
+
+// Return the closing of the set of pixels, in the given scheme, by the structuring element, in increasing
+// order.
+func ClosePixels(hp Healpix, pixels []uint, scheme HealpixScheme, element StructuringElement) []uint {
+	return ErodePixels(hp, DilatePixels(hp, pixels, scheme, element), scheme, element)
+}
@@ -0,0 +1,215 @@
+package healpix
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// A mask of a few random discs and scattered single pixels.
+func randomBlobMask(hp Healpix, scheme HealpixScheme, rng *rand.Rand) []bool {
+	mask := make([]bool, hp.Pixels())
+	for i := 0; i < 4; i++ {
+		for _, p := range QueryDisc(hp, randomPosition(rng), 0.1+0.3*rng.Float64(), scheme, false) {
+			mask[p] = true
+		}
+	}
+	for i := 0; i < 20; i++ {
+		mask[rng.Intn(len(mask))] = true
+	}
+	return mask
+}
+
+// Dilate by checking the distance from every pixel to every set pixel.
+func bruteForceDilate(hp Healpix, mask []bool, scheme HealpixScheme, radius float64) []bool {
+	centers := make([]Vector, len(mask))
+	for p, center := range hp.AllPixelCenters(scheme) {
+		centers[p] = center.ToVector()
+	}
+	result := slices.Clone(mask)
+	for q := range mask {
+		for p, set := range mask {
+			if set && angleBetween(centers[p], centers[q]) <= radius {
+				result[q] = true
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Dilate by walking the given number of steps from every set pixel.
+func bruteForceStepDilate(hp Healpix, mask []bool, scheme HealpixScheme, steps int, connectivity Connectivity) []bool {
+	result := slices.Clone(mask)
+	for p, set := range mask {
+		if !set {
+			continue
+		}
+		frontier := []uint{uint(p)}
+		for step := 0; step < steps; step++ {
+			next := []uint{}
+			for _, f := range frontier {
+				connectivity.neighbors(hp, schemePixel(hp, f, scheme), func(neigh FacePixel) {
+					next = append(next, neigh.PixelId(hp, scheme))
+				})
+			}
+			for _, n := range next {
+				result[n] = true
+			}
+			frontier = next
+		}
+	}
+	return result
+}
+
+func invertMask(mask []bool) []bool {
+	inverse := make([]bool, len(mask))
+	for p, set := range mask {
+		inverse[p] = !set
+	}
+	return inverse
+}
+
+func maskToPixels(mask []bool) []uint {
+	return slices.Collect(maskPixels(mask, true))
+}
+
+func TestDilateErodeByRadius(t *testing.T) {
+	rng := rand.New(rand.NewSource(44))
+	testCases := []struct {
+		name   string
+		order  int
+		scheme HealpixScheme
+		radius float64
+	}{
+		{"below pixel size", 3, NestScheme, 0.1},
+		{"near pixel size", 3, RingScheme, 0.13},
+		{"several pixels", 3, NestScheme, 0.4},
+		{"fine map", 4, RingScheme, 0.2},
+		{"fine map wide", 4, NestScheme, 0.37},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			mask := randomBlobMask(hp, tc.scheme, rng)
+			element := NewRadiusElement(tc.radius)
+
+			expected := bruteForceDilate(hp, mask, tc.scheme, tc.radius)
+			if dilated := Dilate(hp, mask, tc.scheme, element); !slices.Equal(dilated, expected) {
+				t.Errorf("dilated mask differs from brute force")
+			}
+			if dilated := DilatePixels(hp, maskToPixels(mask), tc.scheme, element); !slices.Equal(dilated, maskToPixels(expected)) {
+				t.Errorf("dilated pixels differ from brute force")
+			}
+
+			// erosion is the complement of the dilation of the complement
+			expected = invertMask(bruteForceDilate(hp, invertMask(mask), tc.scheme, tc.radius))
+			if eroded := Erode(hp, mask, tc.scheme, element); !slices.Equal(eroded, expected) {
+				t.Errorf("eroded mask differs from brute force")
+			}
+			if eroded := ErodePixels(hp, maskToPixels(mask), tc.scheme, element); !slices.Equal(eroded, maskToPixels(expected)) {
+				t.Errorf("eroded pixels differ from brute force")
+			}
+		})
+	}
+}
+
+func TestDilateErodeBySteps(t *testing.T) {
+	rng := rand.New(rand.NewSource(45))
+	testCases := []struct {
+		name         string
+		scheme       HealpixScheme
+		steps        int
+		connectivity Connectivity
+	}{
+		{"zero steps", NestScheme, 0, VertexConnectivity},
+		{"one edge step", RingScheme, 1, EdgeConnectivity},
+		{"two vertex steps", NestScheme, 2, VertexConnectivity},
+		{"three edge steps", NestScheme, 3, EdgeConnectivity},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(3))
+			mask := randomBlobMask(hp, tc.scheme, rng)
+			element := NewStepElement(tc.steps, tc.connectivity)
+
+			expected := bruteForceStepDilate(hp, mask, tc.scheme, tc.steps, tc.connectivity)
+			if dilated := Dilate(hp, mask, tc.scheme, element); !slices.Equal(dilated, expected) {
+				t.Errorf("dilated mask differs from brute force")
+			}
+			if dilated := DilatePixels(hp, maskToPixels(mask), tc.scheme, element); !slices.Equal(dilated, maskToPixels(expected)) {
+				t.Errorf("dilated pixels differ from brute force")
+			}
+
+			expected = invertMask(bruteForceStepDilate(hp, invertMask(mask), tc.scheme, tc.steps, tc.connectivity))
+			if eroded := Erode(hp, mask, tc.scheme, element); !slices.Equal(eroded, expected) {
+				t.Errorf("eroded mask differs from brute force")
+			}
+			if eroded := ErodePixels(hp, maskToPixels(mask), tc.scheme, element); !slices.Equal(eroded, maskToPixels(expected)) {
+				t.Errorf("eroded pixels differ from brute force")
+			}
+		})
+	}
+}
+
+func TestOpenClose(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	scheme := NestScheme
+	element := NewRadiusElement(0.1)
+	disc := QueryDisc(hp, NewLatLonCoordinate(0.4, 2), 0.5, scheme, false)
+	mask := make([]bool, hp.Pixels())
+	for _, p := range disc {
+		mask[p] = true
+	}
+
+	// an isolated pixel is too small to survive opening, and a one pixel hole is filled by closing
+	speck := NewLatLonCoordinate(-0.8, 5).PixelId(hp, scheme)
+	hole := NewLatLonCoordinate(0.4, 2).PixelId(hp, scheme)
+	mask[speck] = true
+	mask[hole] = false
+
+	opened := Open(hp, mask, scheme, element)
+	if opened[speck] {
+		t.Errorf("expected opening to remove the isolated pixel")
+	}
+	closed := Close(hp, mask, scheme, element)
+	if !closed[hole] {
+		t.Errorf("expected closing to fill the hole")
+	}
+	// opening only removes pixels, and closing only adds them
+	for p := range mask {
+		if opened[p] && !mask[p] {
+			t.Fatalf("pixel %d: opening added a pixel", p)
+		}
+		if mask[p] && !closed[p] {
+			t.Fatalf("pixel %d: closing removed a pixel", p)
+		}
+	}
+	if pixels := OpenPixels(hp, maskToPixels(mask), scheme, element); !slices.Equal(pixels, maskToPixels(opened)) {
+		t.Errorf("opened pixels differ from opened mask")
+	}
+	if pixels := ClosePixels(hp, maskToPixels(mask), scheme, element); !slices.Equal(pixels, maskToPixels(closed)) {
+		t.Errorf("closed pixels differ from closed mask")
+	}
+}
+
+func TestStructuringElementPanics(t *testing.T) {
+	testCases := []struct {
+		name string
+		make func()
+	}{
+		{"negative steps", func() { NewStepElement(-1, EdgeConnectivity) }},
+		{"invalid connectivity", func() { NewStepElement(1, Connectivity(3)) }},
+		{"negative radius", func() { NewRadiusElement(-0.1) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tc.make()
+		})
+	}
+}
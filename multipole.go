@@ -0,0 +1,252 @@
+package healpix
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// The monopole and dipole of a map, the best fit of m0 + d · v over the unit vectors v of the pixel centers.
+type Dipole struct {
+	monopole float64
+	vector   Vector
+}
+
+// The constant term of the fit over the valid pixels. It is the mean of the map over the full sky only when
+// every pixel is valid; over part of the sky the dipole shifts it away from the mean of the valid pixels.
+func (d Dipole) Monopole() float64 {
+	return d.monopole
+}
+
+// The dipole vector d, whose length is the amplitude and which points to where the dipole is largest.
+func (d Dipole) Vector() Vector {
+	return d.vector
+}
+
+// The amplitude of the dipole, the difference between its largest value and the monopole.
+func (d Dipole) Amplitude() float64 {
+	return d.vector.Length()
+}
+
+// The direction in which the dipole is largest. Arbitrary if the amplitude is 0.
+func (d Dipole) Direction() SphereCoordinate {
+	return d.vector.sphere()
+}
+
+// The value of the fitted monopole and dipole at the position.
+func (d Dipole) Value(hp Healpix, where Where) float64 {
+	return d.monopole + d.vector.dot(toVector(hp, where))
+}
+
+// The spherical harmonic coefficients of the low degrees of a map, found by FitMultipoles.
+type Multipoles struct {
+	a harmonicCoefficients
+}
+
+// The highest degree of the coefficients.
+func (m Multipoles) Lmax() int {
+	return m.a.lmax
+}
+
+// The coefficient of degree l and order m, for 0 <= m <= l <= Lmax, in the convention of healpy: the map is the
+// sum of a_lm Y_lm over l and -l <= m <= l, where a_l,-m is (-1)^m times the conjugate of a_lm. Panics if l or
+// m is out of range.
+func (m Multipoles) Coefficient(l int, order int) complex128 {
+	if order < 0 || order > l || l > m.a.lmax {
+		panic(fmt.Sprintf("healpix: no coefficient of degree %d and order %d up to degree %d", l, order, m.a.lmax))
+	}
+	return m.a.coeffs[m.a.index(l, order)]
+}
+
+// The value of the fitted multipoles at the position.
+func (m Multipoles) Value(hp Healpix, where Where) float64 {
+	v := toVector(hp, where)
+	lambda := make([]float64, len(m.a.coeffs))
+	m.a.legendre(v.z, math.Hypot(v.x, v.y), lambda)
+	lon := math.Atan2(v.y, v.x)
+	value := 0.0
+	for order := 0; order <= m.a.lmax; order++ {
+		factor := 2.0
+		if order == 0 {
+			factor = 1
+		}
+		phase := cmplx.Rect(1, float64(order)*lon)
+		for l := order; l <= m.a.lmax; l++ {
+			i := m.a.index(l, order)
+			value += factor * lambda[i] * real(m.a.coeffs[i]*phase)
+		}
+	}
+	return value
+}
+
+// Return the best fit, in the least squares sense over the valid pixels, of the map indexed in the given
+// scheme to a monopole and a dipole. A pixel is valid if its value is not missing (see IsMissing) and the mask
+// holds true for it, or the mask is nil. The fit is NaN if the valid pixels do not determine it, e.g. if there
+// are fewer than four. Panics if the map or a non-nil mask does not have one value per pixel.
+func FitDipole(hp Healpix, m []float64, mask []bool, scheme HealpixScheme) Dipole {
+	fit := fitRings(hp, m, mask, scheme, 4, func(ring Ring) func(j int, basis []float64) {
+		z, s := ring.Z(), ring.sinColatitude()
+		return func(j int, basis []float64) {
+			lon := ring.LongitudeOf(j)
+			basis[0], basis[1], basis[2], basis[3] = 1, s*math.Cos(lon), s*math.Sin(lon), z
+		}
+	})
+	return Dipole{fit[0], Vector{fit[1], fit[2], fit[3]}}
+}
+
+// Return the map with the monopole and dipole fitted by FitDipole subtracted. The fit uses only the valid
+// pixels, but is subtracted from every pixel with a value, so that the mask can keep e.g. a contaminated region
+// out of the fit; missing values are left as they are. Panics like FitDipole.
+func RemoveDipole(hp Healpix, m []float64, mask []bool, scheme HealpixScheme) []float64 {
+	dipole := FitDipole(hp, m, mask, scheme)
+	return subtractFit(hp, m, scheme, func(center Vector) float64 {
+		return dipole.monopole + dipole.vector.dot(center)
+	})
+}
+
+// Return the best fit, in the least squares sense over the valid pixels, of the map indexed in the given
+// scheme to the spherical harmonics of degree up to lmax, as for FitDipole. Unlike the transforms of a full
+// map, the fit is exact for maps with no power above lmax however much of the sky is masked, but it solves for
+// all (lmax+1)^2 real coefficients together, which costs on the order of Pixels() times lmax^4 operations and
+// suits the low degrees only. Panics if lmax is negative, or like FitDipole.
+func FitMultipoles(hp Healpix, m []float64, mask []bool, scheme HealpixScheme, lmax int) Multipoles {
+	if lmax < 0 {
+		panic(fmt.Sprintf("healpix: multipole degree %d must not be negative", lmax))
+	}
+	a := newHarmonicCoefficients(lmax)
+	lambda := make([]float64, len(a.coeffs))
+	// the real basis functions are lambda for m = 0, and 2 lambda cos(m lon) and -2 lambda sin(m lon) for the
+	// real and imaginary parts of the coefficients of m > 0
+	fit := fitRings(hp, m, mask, scheme, (lmax+1)*(lmax+1), func(ring Ring) func(j int, basis []float64) {
+		a.legendre(ring.Z(), ring.sinColatitude(), lambda)
+		return func(j int, basis []float64) {
+			lon := ring.LongitudeOf(j)
+			k := 0
+			for order := 0; order <= lmax; order++ {
+				c, s := 2*math.Cos(float64(order)*lon), -2*math.Sin(float64(order)*lon)
+				for l := order; l <= lmax; l++ {
+					i := a.index(l, order)
+					if order == 0 {
+						basis[k] = lambda[i]
+						k++
+					} else {
+						basis[k], basis[k+1] = c*lambda[i], s*lambda[i]
+						k += 2
+					}
+				}
+			}
+		}
+	})
+	k := 0
+	for order := 0; order <= lmax; order++ {
+		for l := order; l <= lmax; l++ {
+			if order == 0 {
+				a.coeffs[a.index(l, order)] = complex(fit[k], 0)
+				k++
+			} else {
+				a.coeffs[a.index(l, order)] = complex(fit[k], fit[k+1])
+				k += 2
+			}
+		}
+	}
+	return Multipoles{a}
+}
+
+// Return the map with the multipoles fitted by FitMultipoles subtracted, from every pixel with a value as for
+// RemoveDipole. Panics like FitMultipoles.
+func RemoveMultipoles(hp Healpix, m []float64, mask []bool, scheme HealpixScheme, lmax int) []float64 {
+	multipoles := FitMultipoles(hp, m, mask, scheme, lmax)
+	return subtractFit(hp, m, scheme, func(center Vector) float64 {
+		return multipoles.Value(hp, center)
+	})
+}
+
+// Solve the least squares fit of the valid pixels of the map to n basis functions, ring by ring. For each
+// ring, setup returns the function filling in the basis functions at a pixel of the ring.
+func fitRings(hp Healpix, m []float64, mask []bool, scheme HealpixScheme, n int, setup func(ring Ring) func(j int, basis []float64)) []float64 {
+	checkMapSize(hp, m)
+	if mask != nil {
+		checkMaskSize(hp, mask)
+	}
+	normal := make([][]float64, n)
+	for i := range normal {
+		normal[i] = make([]float64, n)
+	}
+	rhs := make([]float64, n)
+	basis := make([]float64, n)
+	for ring := range hp.AllRings() {
+		fill := setup(ring)
+		for j := 0; j < ring.Pixels(); j++ {
+			p := RingPixel(ring.FirstIndex()+uint(j)).PixelId(hp, scheme)
+			if IsMissing(m[p]) || (mask != nil && !mask[p]) {
+				continue
+			}
+			fill(j, basis)
+			for a := range basis {
+				rhs[a] += basis[a] * m[p]
+				for b := a; b < n; b++ {
+					normal[a][b] += basis[a] * basis[b]
+				}
+			}
+		}
+	}
+	for a := range normal {
+		for b := 0; b < a; b++ {
+			normal[a][b] = normal[b][a]
+		}
+	}
+	return solveLinear(normal, rhs)
+}
+
+// Return the map with the fit at each pixel center subtracted from the pixels with a value.
+func subtractFit(hp Healpix, m []float64, scheme HealpixScheme, fit func(center Vector) float64) []float64 {
+	result := make([]float64, len(m))
+	for p, center := range hp.AllPixelCenters(scheme) {
+		if IsMissing(m[p]) {
+			result[p] = m[p]
+		} else {
+			result[p] = m[p] - fit(center.ToVector())
+		}
+	}
+	return result
+}
+
+// Solve the square linear system by Gaussian elimination with partial pivoting, overwriting both arguments.
+// The solution is NaN if the system is singular.
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	scale := 0.0
+	for i := range a {
+		scale = max(scale, math.Abs(a[i][i]))
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) <= 1e-12*scale {
+			for i := range b {
+				b[i] = math.NaN()
+			}
+			return b
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for j := col; j < n; j++ {
+				a[row][j] -= f * a[col][j]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	for row := n - 1; row >= 0; row-- {
+		for j := row + 1; j < n; j++ {
+			b[row] -= a[row][j] * b[j]
+		}
+		b[row] /= a[row][row]
+	}
+	return b
+}
@@ -0,0 +1,170 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFitDipole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		order    int
+		scheme   HealpixScheme
+		monopole float64
+		dipole   Vector
+		cut      float64 // pixels closer to the equator than this latitude are masked
+	}{
+		{"full sky", 3, NestScheme, 2.5, NewVector(0.3, -0.4, 1.2), 0},
+		{"ring scheme", 3, RingScheme, -1, NewVector(0, 0, -2), 0},
+		{"galactic cut", 4, NestScheme, 10, NewVector(1, 2, 0.5), 0.3},
+		{"no dipole", 2, RingScheme, 4, NewVector(0, 0, 0), 0.5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			m := sampleMap(hp, tc.scheme, func(v Vector) float64 { return tc.monopole + tc.dipole.dot(v) })
+			mask := make([]bool, len(m))
+			for p, center := range hp.AllPixelCenters(tc.scheme) {
+				mask[p] = math.Abs(center.Latitude()) >= tc.cut
+			}
+			// missing pixels are left out of the fit and the removal
+			m[7] = Unseen
+			m[11] = math.NaN()
+
+			dipole := FitDipole(hp, m, mask, tc.scheme)
+			if math.Abs(dipole.Monopole()-tc.monopole) > 1e-12 {
+				t.Errorf("expected monopole %v, got %v", tc.monopole, dipole.Monopole())
+			}
+			if math.Abs(dipole.Amplitude()-tc.dipole.Length()) > 1e-12 {
+				t.Errorf("expected amplitude %v, got %v", tc.dipole.Length(), dipole.Amplitude())
+			}
+			if tc.dipole.Length() > 0 && angleBetween(dipole.Direction().ToVector(), tc.dipole) > 1e-10 {
+				t.Errorf("expected direction %v, got %v", tc.dipole, dipole.Vector())
+			}
+			removed := RemoveDipole(hp, m, mask, tc.scheme)
+			for p, v := range removed {
+				switch p {
+				case 7:
+					if v != Unseen {
+						t.Errorf("expected unseen pixel to stay unseen, got %v", v)
+					}
+				case 11:
+					if !math.IsNaN(v) {
+						t.Errorf("expected NaN pixel to stay NaN, got %v", v)
+					}
+				default:
+					if math.Abs(v) > 1e-12 {
+						t.Fatalf("pixel %d: expected 0 after removal, got %v", p, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFitMultipoles(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	testCases := []struct {
+		name   string
+		order  int
+		scheme HealpixScheme
+		lmax   int
+		cut    float64
+	}{
+		{"monopole", 2, NestScheme, 0, 0},
+		{"quadrupole", 3, RingScheme, 2, 0},
+		{"octupole cut sky", 3, NestScheme, 3, 0.4},
+		{"degree five cut sky", 4, RingScheme, 5, 0.2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			a := newHarmonicCoefficients(tc.lmax)
+			for order := 0; order <= tc.lmax; order++ {
+				for l := order; l <= tc.lmax; l++ {
+					if order == 0 {
+						a.coeffs[a.index(l, order)] = complex(rng.NormFloat64(), 0)
+					} else {
+						a.coeffs[a.index(l, order)] = complex(rng.NormFloat64(), rng.NormFloat64())
+					}
+				}
+			}
+			m := fromRingOrder(hp, a.synthesize(hp), tc.scheme)
+			mask := make([]bool, len(m))
+			for p, center := range hp.AllPixelCenters(tc.scheme) {
+				mask[p] = math.Abs(center.Latitude()) >= tc.cut
+			}
+
+			fit := FitMultipoles(hp, m, mask, tc.scheme, tc.lmax)
+			if fit.Lmax() != tc.lmax {
+				t.Errorf("expected lmax %d, got %d", tc.lmax, fit.Lmax())
+			}
+			for order := 0; order <= tc.lmax; order++ {
+				for l := order; l <= tc.lmax; l++ {
+					expected := a.coeffs[a.index(l, order)]
+					if c := fit.Coefficient(l, order); math.Abs(real(c-expected)) > 1e-9 || math.Abs(imag(c-expected)) > 1e-9 {
+						t.Errorf("l %d m %d: expected %v, got %v", l, order, expected, c)
+					}
+				}
+			}
+			for p, v := range RemoveMultipoles(hp, m, mask, tc.scheme, tc.lmax) {
+				if math.Abs(v) > 1e-9 {
+					t.Fatalf("pixel %d: expected 0 after removal, got %v", p, v)
+				}
+			}
+		})
+	}
+}
+
+func TestFitMultipolesMatchesDipole(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	rng := rand.New(rand.NewSource(50))
+	m := make([]float64, hp.Pixels())
+	for p := range m {
+		m[p] = rng.NormFloat64()
+	}
+	dipole := FitDipole(hp, m, nil, NestScheme)
+	multipoles := FitMultipoles(hp, m, nil, NestScheme, 1)
+	for i := 0; i < 20; i++ {
+		where := randomPosition(rng)
+		if d, mp := dipole.Value(hp, where), multipoles.Value(hp, where); math.Abs(d-mp) > 1e-12 {
+			t.Errorf("at %v: dipole fit %v differs from multipole fit %v", where, d, mp)
+		}
+	}
+}
+
+func TestFitDipoleUnderdetermined(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	m := make([]float64, hp.Pixels())
+	mask := make([]bool, len(m))
+	mask[0], mask[1] = true, true
+	if dipole := FitDipole(hp, m, mask, NestScheme); !math.IsNaN(dipole.Monopole()) {
+		t.Errorf("expected NaN fit from two pixels, got %v", dipole)
+	}
+}
+
+func TestFitMultipolesPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	m := make([]float64, hp.Pixels())
+	testCases := []struct {
+		name string
+		call func()
+	}{
+		{"negative lmax", func() { FitMultipoles(hp, m, nil, NestScheme, -1) }},
+		{"map size", func() { FitDipole(hp, m[1:], nil, NestScheme) }},
+		{"mask size", func() { FitDipole(hp, m, make([]bool, 3), NestScheme) }},
+		{"coefficient out of range", func() { FitMultipoles(hp, m, nil, NestScheme, 1).Coefficient(2, 0) }},
+		{"negative order", func() { FitMultipoles(hp, m, nil, NestScheme, 1).Coefficient(1, -1) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tc.call()
+		})
+	}
+}
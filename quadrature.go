@@ -0,0 +1,182 @@
+package healpix
+
+import (
+	"slices"
+)
+
+// The highest degree of the Legendre polynomials RingWeights integrates exactly, which bounds the cost of
+// computing the weights of fine maps, where the equal areas are already accurate for the higher degrees.
+const maxRingWeightDegree = 512
+
+// Return the integral over the sphere of the function, estimated as the sum of its values at the pixel
+// centers times the pixel area. The function is passed the centers as SphereCoordinate values. Because the
+// pixels have equal areas and the rings of pixel centers are symmetric about the equator and evenly spaced in
+// longitude, the sum is exact for constants and for the spherical harmonics of degree 1. Otherwise the error
+// falls with the square of NSide and hardly depends on how smooth the function is, as it mostly comes from the
+// low degrees: for random functions with no harmonic content above degree 3 NSide, it stays below 2 / NSide^2
+// times their root mean square, e.g. 2e-6 at NSide 1024. Use RingWeights to integrate maps with less error.
+func Integrate(hp Healpix, f func(Where) float64) float64 {
+	sum := 0.0
+	for _, center := range hp.AllPixelCenters(RingScheme) {
+		sum += f(center)
+	}
+	return sum * hp.PixelArea()
+}
+
+// Return the integral of the map over the region, the sum of the values of its pixels times the pixel area.
+// The region holds indices into the map, in whatever scheme the map uses, such as those returned by QueryDisc
+// or QueryPolygon; a nil region integrates over the whole map. Pixels with missing values (see IsMissing)
+// contribute nothing. The error is that of the pixel boundaries, which the region follows, on top of that of
+// Integrate for the pixels within it. Panics if the map does not have one value per pixel.
+func IntegrateMap(hp Healpix, m []float64, region []uint) float64 {
+	checkMapSize(hp, m)
+	sum := 0.0
+	add := func(v float64) {
+		if !IsMissing(v) {
+			sum += v
+		}
+	}
+	if region == nil {
+		for _, v := range m {
+			add(v)
+		}
+	} else {
+		for _, p := range region {
+			add(m[p])
+		}
+	}
+	return sum * hp.PixelArea()
+}
+
+// Quadrature weights for the pixels of each ring of a HEALPix map, which integrate maps more accurately than
+// the equal pixel areas. The weights correct the area of each ring so that the integrals of the Legendre
+// polynomials of the colatitude are exact up to degree 2 NSide + 1, or 513 on maps finer than NSide 256, with
+// the smallest correction in the least squares sense. The integrals of all spherical harmonics up to degree 3
+// are then exact, and so are those of the higher degrees up to that limit, except for the orders that are
+// multiples of 4, which alias on the small rings near the poles. For random functions with no harmonic content
+// above degree NSide / 2, the error is several hundred times smaller than that of Integrate, and above degree
+// NSide it is 20 to 50 times smaller, but there is little gain above degree 2 NSide. Computing the weights
+// costs on the order of NSide times the square of the exact degree, so compute them once and reuse them for
+// every map of the same resolution.
+type RingWeights struct {
+	hp      Healpix
+	weights []float64
+}
+
+// Compute the quadrature weights of the rings of the HEALPix map.
+func NewRingWeights(hp Healpix) RingWeights {
+	north := hp.EquatorRing() + 1
+	// each northern ring stands for itself and its southern mirror, with the pixels of both
+	z := make([]float64, north)
+	count := make([]float64, north)
+	for r := range north {
+		ring := NewRing(hp, r)
+		z[r] = ring.Z()
+		count[r] = float64(2 * ring.Pixels())
+	}
+	count[north-1] /= 2
+	// the polynomials of even degree; those of odd degree integrate to 0 by symmetry
+	top := min(2*hp.FaceSidePixels(), maxRingWeightDegree)
+	degrees := top/2 + 1
+	legendre := make([][]float64, degrees)
+	for k := range legendre {
+		legendre[k] = make([]float64, north)
+	}
+	for r, zr := range z {
+		previous, current := 1.0, zr
+		legendre[0][r] = 1
+		for l := 2; l <= top; l++ {
+			previous, current = current, (float64(2*l-1)*zr*current-float64(l-1)*previous)/float64(l)
+			if l%2 == 0 {
+				legendre[l/2][r] = current
+			}
+		}
+	}
+	// the correction d of the relative weights solves sum_r count_r P_l(z_r) d_r = -sum_r count_r P_l(z_r) for
+	// l > 0 with the least sum_r count_r d_r^2, which is d_r = sum_l P_l(z_r) c_l for the c solving G c = b with
+	// the Gram matrix of the polynomials over the rings
+	gram := make([][]float64, degrees)
+	rhs := make([]float64, degrees)
+	for k := range gram {
+		gram[k] = make([]float64, degrees)
+		for j := 0; j <= k; j++ {
+			sum := 0.0
+			for r := range z {
+				sum += count[r] * legendre[k][r] * legendre[j][r]
+			}
+			gram[k][j], gram[j][k] = sum, sum
+		}
+		if k > 0 {
+			for r := range z {
+				rhs[k] -= count[r] * legendre[k][r]
+			}
+		}
+	}
+	c := solveLinear(gram, rhs)
+	weights := make([]float64, hp.Rings())
+	for r := range z {
+		correction := 0.0
+		for k := range c {
+			correction += c[k] * legendre[k][r]
+		}
+		weights[r] = hp.PixelArea() * (1 + correction)
+		weights[hp.Rings()-1-r] = weights[r]
+	}
+	return RingWeights{hp, weights}
+}
+
+// The HEALPix map the weights are for.
+func (w RingWeights) Healpix() Healpix {
+	return w.hp
+}
+
+// The weight of each pixel of the ring, in steradians, close to the pixel area. Panics if the ring index is
+// invalid.
+func (w RingWeights) Weight(ring int) float64 {
+	NewRing(w.hp, ring)
+	return w.weights[ring]
+}
+
+// The weights of each ring, in ring index order. Changes to the returned slice do not change the weights.
+func (w RingWeights) Weights() []float64 {
+	return slices.Clone(w.weights)
+}
+
+// Visit the weight of each pixel of the map indexed in the given scheme.
+func (w RingWeights) eachPixel(scheme HealpixScheme, visit func(p uint, weight float64)) {
+	for ring := range w.hp.AllRings() {
+		weight := w.weights[ring.Index()]
+		first := ring.FirstIndex()
+		for j := uint(0); j < uint(ring.Pixels()); j++ {
+			visit(RingPixel(first+j).PixelId(w.hp, scheme), weight)
+		}
+	}
+}
+
+// Return the integral over the sphere of the map indexed in the given scheme. Pixels with missing values (see
+// IsMissing) contribute nothing. Panics if the map does not have one value per pixel.
+func (w RingWeights) Integrate(m []float64, scheme HealpixScheme) float64 {
+	checkMapSize(w.hp, m)
+	sum := 0.0
+	w.eachPixel(scheme, func(p uint, weight float64) {
+		if !IsMissing(m[p]) {
+			sum += weight * m[p]
+		}
+	})
+	return sum
+}
+
+// Return the mean over the sphere of the map indexed in the given scheme, or over the pixels with values if
+// some are missing (see IsMissing). NaN if every value is missing. Panics if the map does not have one value
+// per pixel.
+func (w RingWeights) Mean(m []float64, scheme HealpixScheme) float64 {
+	checkMapSize(w.hp, m)
+	sum, total := 0.0, 0.0
+	w.eachPixel(scheme, func(p uint, weight float64) {
+		if !IsMissing(m[p]) {
+			sum += weight * m[p]
+			total += weight
+		}
+	})
+	return sum / total
+}
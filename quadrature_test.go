@@ -0,0 +1,189 @@
+package healpix
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// A random map with no harmonic content above degree lmax and a mean of 0, in the given scheme.
+func randomBandLimitedMap(hp Healpix, scheme HealpixScheme, lmax int, rng *rand.Rand) []float64 {
+	a := newHarmonicCoefficients(lmax)
+	for order := 0; order <= lmax; order++ {
+		for l := max(order, 1); l <= lmax; l++ {
+			if order == 0 {
+				a.coeffs[a.index(l, order)] = complex(rng.NormFloat64(), 0)
+			} else {
+				a.coeffs[a.index(l, order)] = complex(rng.NormFloat64(), rng.NormFloat64())
+			}
+		}
+	}
+	return fromRingOrder(hp, a.synthesize(hp), scheme)
+}
+
+func rootMeanSquare(m []float64) float64 {
+	sum := 0.0
+	for _, v := range m {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(m)))
+}
+
+func TestIntegrate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		order     int
+		f         func(Where) float64
+		expected  float64
+		tolerance float64
+	}{
+		{"constant", 3, func(Where) float64 { return 1 }, 4 * math.Pi, 1e-14},
+		{"dipole", 3, func(w Where) float64 { return NewVector(1, -2, 3).dot(w.(SphereCoordinate).ToVector()) }, 0, 1e-13},
+		{"coarse square of z", 2, func(w Where) float64 { z := w.(SphereCoordinate).ToVector().z; return z * z }, 4 * math.Pi / 3, 2.0 / 16},
+		{"fine square of z", 6, func(w Where) float64 { z := w.(SphereCoordinate).ToVector().z; return z * z }, 4 * math.Pi / 3, 2.0 / 4096},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			if integral := Integrate(hp, tc.f); math.Abs(integral-tc.expected) > tc.tolerance*4*math.Pi {
+				t.Errorf("expected integral %v, got %v", tc.expected, integral)
+			}
+		})
+	}
+}
+
+func TestIntegrateMap(t *testing.T) {
+	hp := New(NewHealpixOrder(5))
+	ones := make([]float64, hp.Pixels())
+	for p := range ones {
+		ones[p] = 1
+	}
+	if integral := IntegrateMap(hp, ones, nil); !withinTolerance(integral, 4*math.Pi, 1e-14) {
+		t.Errorf("expected full sky integral 4 pi, got %v", integral)
+	}
+	// the pixels of a disc cover about its area
+	radius := 0.5
+	disc := QueryDisc(hp, NewLatLonCoordinate(0.3, 1), radius, NestScheme, false)
+	if integral := IntegrateMap(hp, ones, disc); !withinTolerance(integral, 2*math.Pi*(1-math.Cos(radius)), 0.01) {
+		t.Errorf("expected disc integral %v, got %v", 2*math.Pi*(1-math.Cos(radius)), integral)
+	}
+	ones[disc[0]] = Unseen
+	ones[disc[1]] = math.NaN()
+	if integral := IntegrateMap(hp, ones, disc); !withinTolerance(integral, float64(len(disc)-2)*hp.PixelArea(), 1e-14) {
+		t.Errorf("expected missing pixels to be skipped, got %v", integral)
+	}
+}
+
+func TestRingWeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(50))
+	testCases := []struct {
+		name  string
+		order int
+	}{
+		{"nside 1", 0},
+		{"nside 2", 1},
+		{"nside 8", 3},
+		{"nside 32", 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(NewHealpixOrder(tc.order))
+			weights := NewRingWeights(hp)
+			nside := hp.FaceSidePixels()
+
+			total := 0.0
+			for ring := range hp.AllRings() {
+				if weights.Weight(ring.Index()) != weights.Weight(hp.Rings()-1-ring.Index()) {
+					t.Errorf("ring %d: expected weights symmetric about the equator", ring.Index())
+				}
+				total += weights.Weight(ring.Index()) * float64(ring.Pixels())
+			}
+			if !withinTolerance(total, 4*math.Pi, 1e-13) {
+				t.Errorf("expected weights to sum to 4 pi, got %v", total)
+			}
+
+			// polynomials of z up to degree 2 NSide + 1 integrate exactly
+			for degree := 0; degree <= 2*nside+1; degree++ {
+				m := sampleMap(hp, NestScheme, func(v Vector) float64 { return math.Pow(v.z, float64(degree)) })
+				expected := 0.0
+				if degree%2 == 0 {
+					expected = 4 * math.Pi / float64(degree+1)
+				}
+				if integral := weights.Integrate(m, NestScheme); math.Abs(integral-expected) > 1e-12*4*math.Pi {
+					t.Errorf("z^%d: expected integral %v, got %v", degree, expected, integral)
+				}
+			}
+
+			// every harmonic up to degree 3 integrates exactly
+			m := randomBandLimitedMap(hp, RingScheme, 3, rng)
+			if integral := weights.Integrate(m, RingScheme); math.Abs(integral) > 1e-12*rootMeanSquare(m) {
+				t.Errorf("expected integral 0 up to degree 3, got %v", integral)
+			}
+		})
+	}
+}
+
+func TestRingWeightsMoreAccurate(t *testing.T) {
+	rng := rand.New(rand.NewSource(51))
+	hp := New(NewHealpixOrder(5))
+	weights := NewRingWeights(hp)
+	for i := 0; i < 3; i++ {
+		m := randomBandLimitedMap(hp, NestScheme, 16, rng)
+		rms := rootMeanSquare(m)
+		naive := math.Abs(IntegrateMap(hp, m, nil))
+		weighted := math.Abs(weights.Integrate(m, NestScheme))
+		bound := 2 * rms / (32 * 32)
+		if naive > bound {
+			t.Errorf("equal area error %v beyond the documented bound %v", naive, bound)
+		}
+		if weighted*100 > bound {
+			t.Errorf("expected ring weights error %v to be far below the equal area bound %v", weighted, bound)
+		}
+	}
+}
+
+func TestRingWeightsMean(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	weights := NewRingWeights(hp)
+	m := sampleMap(hp, RingScheme, func(v Vector) float64 { return 2 + v.z*v.z })
+	if mean := weights.Mean(m, RingScheme); !withinTolerance(mean, 2+1.0/3, 1e-14) {
+		t.Errorf("expected mean %v, got %v", 2+1.0/3, mean)
+	}
+	// missing pixels are left out of the mean
+	m = sampleMap(hp, NestScheme, func(v Vector) float64 { return 5 })
+	m[3], m[40] = Unseen, math.NaN()
+	if mean := weights.Mean(m, NestScheme); !withinTolerance(mean, 5, 1e-13) {
+		t.Errorf("expected mean 5, got %v", mean)
+	}
+	for p := range m {
+		m[p] = Unseen
+	}
+	if mean := weights.Mean(m, NestScheme); !math.IsNaN(mean) {
+		t.Errorf("expected NaN mean without values, got %v", mean)
+	}
+}
+
+func TestRingWeightsPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	weights := NewRingWeights(hp)
+	testCases := []struct {
+		name string
+		call func()
+	}{
+		{"negative ring", func() { weights.Weight(-1) }},
+		{"ring past the south pole", func() { weights.Weight(hp.Rings()) }},
+		{"map size", func() { weights.Integrate(make([]float64, 3), NestScheme) }},
+		{"mean map size", func() { weights.Mean(make([]float64, 3), NestScheme) }},
+		{"integrate map size", func() { IntegrateMap(hp, make([]float64, 3), nil) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tc.call()
+		})
+	}
+}
@@ -1,142 +1,535 @@
 package healpix
 
-// Given a desired coordinate on a healpix map, return the pixel index of
-// of the desired neighbor pixel of in the selected HEALPix numbering scheme.
-func Neighbor(hp Healpix, scheme HealpixScheme, where Where, xo int, yo int) uint {
-	fp := where.ToFacePixel(hp)
-	maxXY := hp.FaceSidePixels() - 1
+import (
+	"fmt"
+	"math"
+)
 
-	x := fp.x + xo
-	y := fp.y + yo
-	// these track whether the neighbor is in a different face, and which x/y direction the face is in
-	fxdir := 0
-	fydir := 0
+// One of the eight compass directions from a pixel to its neighbors. Directions are relative to the
+// diamond shaped pixels: North is the pixel across the northern vertex, NorthEast is the pixel across the
+// north-eastern edge, and so on. The order of the constants is the order in which the neighbors are
+// visited clockwise from the south; it differs from the order used by healpy's get_all_neighbours,
+// which starts at the south-west.
+type Direction int
 
-	if x < 0 {
-		fxdir = -1
-		x += hp.FaceSidePixels()
-	} else if x >= hp.FaceSidePixels() {
-		fxdir = 1
-		x -= hp.FaceSidePixels()
+const (
+	South Direction = iota
+	SouthEast
+	East
+	NorthEast
+	North
+	NorthWest
+	West
+	SouthWest
+)
+
+// The x/y offsets in face pixel coordinates of each direction. X increases to the north-east and y to
+// the north-west, so e.g. South is one step down in both.
+var directionOffsets = [8][2]int{
+	{-1, -1}, // South
+	{0, -1},  // SouthEast
+	{1, -1},  // East
+	{1, 0},   // NorthEast
+	{1, 1},   // North
+	{0, 1},   // NorthWest
+	{-1, 1},  // West
+	{-1, 0},  // SouthWest
+}
+
+var directionNames = [8]string{"S", "SE", "E", "NE", "N", "NW", "W", "SW"}
+
+// Return the direction with the given x/y offsets in face pixel coordinates. Each of x and y expects one
+// of three values: -1, 0, or 1, and they may not both be 0. Panics otherwise.
+func NewDirection(xOffset int, yOffset int) Direction {
+	for d, offsets := range directionOffsets {
+		if offsets[0] == xOffset && offsets[1] == yOffset {
+			return Direction(d)
+		}
 	}
+	panic(fmt.Sprintf("healpix: there is no neighbor direction with offsets %v,%v", xOffset, yOffset))
+}
 
-	if y < 0 {
-		fydir = -1
-		y += hp.FaceSidePixels()
-	} else if y >= hp.FaceSidePixels() {
-		fydir = 1
-		y -= hp.FaceSidePixels()
+// The x/y offsets in face pixel coordinates of the direction.
+func (d Direction) Offsets() (int, int) {
+	return directionOffsets[d][0], directionOffsets[d][1]
+}
+
+// The direction pointing the opposite way.
+func (d Direction) Opposite() Direction {
+	return (d + 4) % 8
+}
+
+// The abbreviated compass name of the direction, e.g. "NE".
+func (d Direction) String() string {
+	if d < South || d > SouthWest {
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+	return directionNames[d]
+}
+
+// The neighboring face in each face-offset direction, indexed by 4 + xOffset + 3*yOffset and then by face.
+// A -1 marks the directions in which only three faces meet at a vertex, so there is no neighbor.
+var neighborFaces = [9][12]int{
+	{8, 9, 10, 11, -1, -1, -1, -1, 10, 11, 8, 9}, // South
+	{5, 6, 7, 4, 8, 9, 10, 11, 9, 10, 11, 8},     // SouthEast
+	{-1, -1, -1, -1, 5, 6, 7, 4, -1, -1, -1, -1}, // East
+	{4, 5, 6, 7, 11, 8, 9, 10, 11, 8, 9, 10},     // SouthWest
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},       // the face itself
+	{1, 2, 3, 0, 0, 1, 2, 3, 5, 6, 7, 4},         // NorthEast
+	{-1, -1, -1, -1, 7, 4, 5, 6, -1, -1, -1, -1}, // West
+	{3, 0, 1, 2, 3, 0, 1, 2, 4, 5, 6, 7},         // NorthWest
+	{2, 3, 0, 1, -1, -1, -1, -1, 0, 1, 2, 3},     // North
+}
+
+// How the x/y coordinates must be transformed when stepping into the neighboring face, indexed like
+// neighborFaces and then by row of faces. Bit 1 flips x, bit 2 flips y, and bit 4 swaps x and y. Only the
+// polar faces need a transformation, as their edges around the poles are rotated relative to each other.
+var neighborSwaps = [9][3]int{
+	{0, 0, 3}, // South
+	{0, 0, 6}, // SouthEast
+	{0, 0, 0}, // East
+	{0, 0, 5}, // SouthWest
+	{0, 0, 0}, // the face itself
+	{5, 0, 0}, // NorthEast
+	{0, 0, 0}, // West
+	{6, 0, 0}, // NorthWest
+	{3, 0, 0}, // North
+}
+
+// Find the neighbor of a face pixel in the given direction, stepping across face edges where needed.
+// Returns false if there is no neighbor in that direction, which happens for the pixels at the vertices
+// where only three faces meet.
+func neighborFacePixel(hp Healpix, fp FacePixel, dir Direction) (FacePixel, bool) {
+	xo, yo := dir.Offsets()
+	return facePixelAt(hp, fp.face, fp.x+xo, fp.y+yo)
+}
+
+// Find the pixel at the face coordinates x, y of the given face, where coordinates outside the face (by at
+// most one face width) continue across its edges into the neighboring faces, as the pixel grid continues
+// unbroken across face edges. Returns false if the position lies in the corner beyond a vertex where only
+// three faces meet, where there is no pixel.
+func facePixelAt(hp Healpix, face int, x int, y int) (FacePixel, bool) {
+	nside := hp.FaceSidePixels()
+	if x >= 0 && x < nside && y >= 0 && y < nside {
+		// highest probability branch in higher resolutions
+		return FacePixel{x, y, face}, true
 	}
 
-	if fxdir == 1 && fydir != -1 && fp.face < 4 {
-		x = y
-		y = maxXY
+	// which neighboring face the pixel is in
+	nb := 4
+	if x < 0 {
+		x += nside
+		nb -= 1
+	} else if x >= nside {
+		x -= nside
+		nb += 1
 	}
-	if fydir == 1 && fxdir != -1 && fp.face < 4 {
-		y = x
-		x = maxXY
+	if y < 0 {
+		y += nside
+		nb -= 3
+	} else if y >= nside {
+		y -= nside
+		nb += 3
 	}
 
-	if fydir == -1 && fxdir != 1 && fp.face > 7 {
-		y = x
-		x = 0
+	neighbor := neighborFaces[nb][face]
+	if neighbor < 0 {
+		return FacePixel{}, false
 	}
-	if fxdir == -1 && fydir != 1 && fp.face > 7 {
-		x = y
-		y = 0
+	swap := neighborSwaps[nb][face>>2]
+	if swap&1 != 0 {
+		x = nside - x - 1
 	}
+	if swap&2 != 0 {
+		y = nside - y - 1
+	}
+	if swap&4 != 0 {
+		x, y = y, x
+	}
+	return FacePixel{x, y, neighbor}, true
+}
+
+// Given a desired coordinate on a healpix map, return the pixel index of
+// of the desired neighbor pixel of in the selected HEALPix numbering scheme.
+// The offsets are those of NewDirection. Panics if there is no neighbor in
+// that direction.
+func Neighbor(hp Healpix, scheme HealpixScheme, where Where, xo int, yo int) uint {
+	dir := NewDirection(xo, yo)
+	neigh, ok := neighborFacePixel(hp, where.ToFacePixel(hp), dir)
+	if !ok {
+		panic(fmt.Sprintf("healpix: tried to get a neighbor of a pixel that has no neighbor in direction %v", dir))
+	}
+	return neigh.PixelId(hp, scheme)
+}
 
-	face := NewFace(fp.face).Neighbor(fxdir, fydir)
-	return FacePixel{x, y, face}.PixelId(hp, scheme)
+// Given a desired coordinate on a healpix map, write the pixel index of the neighbor in each
+// direction into result, indexed by Direction, in the HEALPix index scheme desired. Pixels at
+// the vertices where only three faces meet have no neighbor in one direction, which is marked
+// with -1, like healpy's get_all_neighbours. Does not allocate.
+func NeighborsInto(hp Healpix, where Where, scheme HealpixScheme, result *[8]int64) {
+	fp := where.ToFacePixel(hp)
+	for d := South; d <= SouthWest; d++ {
+		if neigh, ok := neighborFacePixel(hp, fp, d); ok {
+			result[d] = int64(neigh.PixelId(hp, scheme))
+		} else {
+			result[d] = -1
+		}
+	}
 }
 
+// The order in which Neighbors returns the neighbors of a pixel: upward through the rows of
+// the 3x3 block of face pixel offsets around the pixel.
+var neighborsOrder = [8]Direction{South, SouthEast, East, SouthWest, NorthEast, West, NorthWest, North}
+
 // Given a desired coordinate on a healpix map, return the pixel indices
 // of each neighbor pixel of the selected coordinate in the HEALPix index
-// scheme desired.
+// scheme desired. Missing neighbors are left out, so the result has 7 or 8
+// entries. Use NeighborsInto to know which neighbor lies in which direction.
 func Neighbors(hp Healpix, where Where, scheme HealpixScheme) []uint {
 	fp := where.ToFacePixel(hp)
-	maxXY := hp.FaceSidePixels() - 1
+	result := make([]uint, 0, 8)
+	for _, d := range neighborsOrder {
+		if neigh, ok := neighborFacePixel(hp, fp, d); ok {
+			result = append(result, neigh.PixelId(hp, scheme))
+		}
+	}
+	return result
+}
 
-	if fp.x > 0 && fp.x < maxXY && fp.y > 0 && fp.y < maxXY {
-		result := make([]uint, 8)
-		// pixel not on face boundary
-		// highest probability branch in higher resolutions
-		// TODO: nested can be even faster here as a special case, doesn't have to go through FacePixel first
-		result[0] = FacePixel{fp.x - 1, fp.y - 1, fp.face}.PixelId(hp, scheme)
-		result[1] = FacePixel{fp.x, fp.y - 1, fp.face}.PixelId(hp, scheme)
-		result[2] = FacePixel{fp.x + 1, fp.y - 1, fp.face}.PixelId(hp, scheme)
-		result[3] = FacePixel{fp.x - 1, fp.y, fp.face}.PixelId(hp, scheme)
-		result[4] = FacePixel{fp.x + 1, fp.y, fp.face}.PixelId(hp, scheme)
-		result[5] = FacePixel{fp.x - 1, fp.y + 1, fp.face}.PixelId(hp, scheme)
-		result[6] = FacePixel{fp.x, fp.y + 1, fp.face}.PixelId(hp, scheme)
-		result[7] = FacePixel{fp.x + 1, fp.y + 1, fp.face}.PixelId(hp, scheme)
-		return result
+// A pixel crossed by a polyline, along with the positions at which the polyline enters and leaves it.
+type PolylineCrossing struct {
+	pixel   uint
+	segment int
+	entry   SphereCoordinate
+	exit    SphereCoordinate
+}
+
+// The index of the crossed pixel in the requested numbering scheme.
+func (c PolylineCrossing) Pixel() uint {
+	return c.pixel
+}
+
+// The index of the segment on which the polyline enters the pixel, where segment i runs from
+// points[i] to points[i+1].
+func (c PolylineCrossing) Segment() int {
+	return c.segment
+}
+
+// The position at which the polyline enters the pixel. For the first pixel this is the first point.
+func (c PolylineCrossing) Entry() SphereCoordinate {
+	return c.entry
+}
+
+// The position at which the polyline leaves the pixel. For the last pixel this is the last point.
+func (c PolylineCrossing) Exit() SphereCoordinate {
+	return c.exit
+}
+
+// Return, in order, every pixel crossed by the polyline made of the shortest great circle arcs between
+// consecutive points, along with the positions where the polyline enters and leaves each pixel. A pixel
+// appears once for every separate visit of the polyline, but never twice in a row, including where two
+// segments meet. Pixel boundaries are located by bisection to within floating point precision, so even
+// pixels whose corners are only clipped by the path are included. Panics if two consecutive points are
+// antipodal, as the great circle between them is undefined.
+func QueryPolyline(hp Healpix, points []Where, scheme HealpixScheme) []PolylineCrossing {
+	result := []PolylineCrossing{}
+	last := FacePixel{face: -1}
+	for i, arc := range polylineArcs(hp, points) {
+		traverseArc(hp, arc, func(fp FacePixel, entry float64, exit float64) {
+			exitPos := arc.at(exit).ToSphereCoordinate(hp)
+			if fp == last {
+				result[len(result)-1].exit = exitPos
+				return
+			}
+			last = fp
+			result = append(result, PolylineCrossing{
+				fp.PixelId(hp, scheme),
+				i,
+				arc.at(entry).ToSphereCoordinate(hp),
+				exitPos,
+			})
+		})
 	}
+	return result
+}
 
-	// pixel is on an edge boundary, we need to be cognizant of
-	// special corner pixels and edge index swapping
+// Return every pixel that may come within halfWidth radians of the polyline made of the shortest great circle
+// arcs between consecutive points. The pixels crossed by the polyline come first, in the order of
+// QueryPolyline but without repeats, followed by the remaining pixels of the corridor in breadth first
+// order outward from the path. The result is a conservative superset, like the inclusive QueryDisc: a pixel
+// is kept when its center lies within halfWidth plus the pixel radius of the path, so every pixel that
+// overlaps the corridor is returned, along with some that lie up to a pixel radius beyond its edge, even for
+// a zero halfWidth. Each pixel kept or rejected costs one distance to each arc of the polyline.
+func QueryPolylineCorridor(hp Healpix, points []Where, halfWidth float64, scheme HealpixScheme) []uint {
+	arcs := polylineArcs(hp, points)
+	seen := map[FacePixel]struct{}{}
+	frontier := []FacePixel{}
+	for _, arc := range arcs {
+		traverseArc(hp, arc, func(fp FacePixel, entry float64, exit float64) {
+			if _, found := seen[fp]; !found {
+				seen[fp] = struct{}{}
+				frontier = append(frontier, fp)
+			}
+		})
+	}
 
 	result := []uint{}
-	iterLen := 9
-	// account for special pixels that only have 7 neighbors
-	if fp.face < 8 && fp.x == maxXY && fp.y == maxXY {
-		iterLen = 8
-	}
-	iterStart := 0
-	if fp.face > 3 && fp.x == 0 && fp.y == 0 {
-		iterStart = 1
-	}
-	for i := iterStart; i < iterLen; i++ {
-		xo := i%3 - 1
-		yo := (i / 3) - 1
-		// we iterate over the pixel itself but don't include it in the result set
-		if xo == 0 && yo == 0 {
-			continue
+	for len(frontier) > 0 {
+		next := []FacePixel{}
+		for _, fp := range frontier {
+			result = append(result, fp.PixelId(hp, scheme))
+			for d := South; d <= SouthWest; d++ {
+				neigh, ok := neighborFacePixel(hp, fp, d)
+				if !ok {
+					continue
+				}
+				if _, found := seen[neigh]; found {
+					continue
+				}
+				if pixelWithinDistance(hp, neigh, arcs, halfWidth) {
+					seen[neigh] = struct{}{}
+					next = append(next, neigh)
+				}
+			}
 		}
+		frontier = next
+	}
+	return result
+}
 
-		x := fp.x + xo
-		y := fp.y + yo
-		// these track whether the neighbor is in a different face, and which x/y direction the face is in
-		fxdir := 0
-		fydir := 0
+// The great circle arcs between consecutive points. A single point becomes a zero length arc.
+func polylineArcs(hp Healpix, points []Where) []greatArc {
+	if len(points) == 0 {
+		return nil
+	}
+	if len(points) == 1 {
+		v := toVector(hp, points[0])
+		return []greatArc{newGreatArc(v, v)}
+	}
+	arcs := make([]greatArc, 0, len(points)-1)
+	prev := toVector(hp, points[0])
+	for _, p := range points[1:] {
+		v := toVector(hp, p)
+		arcs = append(arcs, newGreatArc(prev, v))
+		prev = v
+	}
+	return arcs
+}
 
-		if x < 0 {
-			fxdir = -1
-			x += hp.FaceSidePixels()
-		} else if x >= hp.FaceSidePixels() {
-			fxdir = 1
-			x -= hp.FaceSidePixels()
+// Walk along the arc calling visit for each pixel crossed, in order, with the angles along the arc at
+// which the arc enters and leaves the pixel. The arc is sampled several times per pixel to find a point
+// beyond the current pixel, and the exact exit is then found by bisection.
+func traverseArc(hp Healpix, arc greatArc, visit func(fp FacePixel, entry float64, exit float64)) {
+	step := hp.AngularResolution() / 8
+	theta := 0.0
+	current := arc.at(0).ToFacePixel(hp)
+	for {
+		// find the first sample that is no longer in the current pixel
+		inside := theta
+		outside := -1.0
+		for s := theta + step; inside < arc.length; s += step {
+			s = min(s, arc.length)
+			if arc.at(s).ToFacePixel(hp) != current {
+				outside = s
+				break
+			}
+			inside = s
 		}
-
-		if y < 0 {
-			fydir = -1
-			y += hp.FaceSidePixels()
-		} else if y >= hp.FaceSidePixels() {
-			fydir = 1
-			y -= hp.FaceSidePixels()
+		if outside < 0 {
+			visit(current, theta, arc.length)
+			return
 		}
 
-		if fxdir == 1 && fydir != -1 && fp.face < 4 {
-			x = y
-			y = maxXY
+		// narrow down the exit until the two angles are adjacent floating point values
+		for {
+			mid := inside + (outside-inside)/2
+			if mid <= inside || mid >= outside {
+				break
+			}
+			if arc.at(mid).ToFacePixel(hp) == current {
+				inside = mid
+			} else {
+				outside = mid
+			}
 		}
-		if fydir == 1 && fxdir != -1 && fp.face < 4 {
-			y = x
-			x = maxXY
+		visit(current, theta, inside)
+		current = arc.at(outside).ToFacePixel(hp)
+		theta = outside
+	}
+}
+
+// Whether the pixel may come within distance of one of the arcs, because its center lies within distance plus
+// the pixel radius of the arc.
+func pixelWithinDistance(hp Healpix, fp FacePixel, arcs []greatArc, distance float64) bool {
+	center := fp.ToSphereCoordinate(hp).ToVector()
+	bound := distance + facePixelRadius(hp, fp)
+	for _, arc := range arcs {
+		if arc.distance(center) <= bound {
+			return true
 		}
+	}
+	return false
+}
 
-		if fydir == -1 && fxdir != 1 && fp.face > 7 {
-			y = x
-			x = 0
+// Return the pixels of a disc of the given angular radius (in radians) around the center position, in the
+// HEALPix index scheme desired. If inclusive is false, a pixel is returned when its center lies within the
+// disc, like healpy's query_disc. If inclusive is true, every pixel that may overlap the disc is returned,
+// which can include a few pixels just outside it. Pixels are in breadth first order outward from the center.
+func QueryDisc(hp Healpix, center Where, radius float64, scheme HealpixScheme, inclusive bool) []uint {
+	c := toVector(hp, center)
+	result := []uint{}
+	visitDisc(hp, c, radius, func(fp FacePixel, centerDistance float64, pixelRadius float64) {
+		if centerDistance <= radius || (inclusive && centerDistance <= radius+pixelRadius) {
+			result = append(result, fp.PixelId(hp, scheme))
 		}
-		if fxdir == -1 && fydir != 1 && fp.face > 7 {
-			x = y
-			y = 0
+	})
+	return result
+}
+
+// Return the pixels of a convex spherical polygon with great circle edges between the given vertices, in
+// the HEALPix index scheme desired. The vertices may wind in either direction, and the polygon must be
+// smaller than a hemisphere. If inclusive is false, a pixel is returned when its center lies within the
+// polygon, like healpy's query_polygon. If inclusive is true, every pixel that may overlap the polygon is
+// returned. Panics if there are fewer than three vertices, or if the polygon is not convex, i.e. if any vertex
+// lies outside the great circle of one of the edges. Split a concave polygon into convex ones to query it.
+func QueryPolygon(hp Healpix, vertices []Where, scheme HealpixScheme, inclusive bool) []uint {
+	poly := newConvexPolygon(hp, vertices)
+	result := []uint{}
+	visitDisc(hp, poly.center, poly.radius, func(fp FacePixel, centerDistance float64, pixelRadius float64) {
+		c := fp.ToSphereCoordinate(hp).ToVector()
+		if poly.contains(c) || (inclusive && poly.boundaryDistance(c) <= pixelRadius) {
+			result = append(result, fp.PixelId(hp, scheme))
 		}
+	})
+	return result
+}
 
-		face := NewFace(fp.face).Neighbor(fxdir, fydir)
-		neigh := FacePixel{x, y, face}.PixelId(hp, scheme)
-		result = append(result, neigh)
+// Return the pixels of the strip between two colatitudes (in radians, 0 at the north pole and Pi at the south
+// pole), in the HEALPix index scheme desired. The colatitudes may be given in either order. If inclusive is
+// false, a pixel is returned when its center lies within the strip, like healpy's query_strip. If inclusive
+// is true, every pixel that overlaps the strip is returned. Pixels are in ring order, from north to south.
+func QueryStrip(hp Healpix, colat1 float64, colat2 float64, scheme HealpixScheme, inclusive bool) []uint {
+	north := min(colat1, colat2)
+	south := max(colat1, colat2)
+	result := []uint{}
+	for ringId := 0; ringId < hp.Rings(); ringId++ {
+		ring := NewRing(hp, ringId)
+		colat := ring.Colatitude()
+		overlaps := colat >= north && colat <= south
+		if inclusive {
+			overlaps = ring.NorthColatitude() <= south && ring.SouthColatitude() >= north
+		}
+		if !overlaps {
+			continue
+		}
+		for i := 0; i < ring.Pixels(); i++ {
+			pixel := RingPixel(ring.FirstIndex() + uint(i))
+			result = append(result, pixel.PixelId(hp, scheme))
+		}
 	}
 	return result
 }
+
+// Visit every pixel that may overlap the disc around the unit vector center, in breadth first order, with
+// the angular distance from the disc center to the pixel center and the angular radius of the pixel.
+func visitDisc(hp Healpix, center Vector, radius float64, visit func(fp FacePixel, centerDistance float64, pixelRadius float64)) {
+	start := center.ToFacePixel(hp)
+	seen := map[FacePixel]struct{}{start: {}}
+	frontier := []FacePixel{start}
+	for len(frontier) > 0 {
+		next := []FacePixel{}
+		for _, fp := range frontier {
+			distance := angleBetween(center, fp.ToSphereCoordinate(hp).ToVector())
+			pixelRadius := facePixelRadius(hp, fp)
+			if distance > radius+pixelRadius {
+				continue
+			}
+			visit(fp, distance, pixelRadius)
+			for d := South; d <= SouthWest; d++ {
+				neigh, ok := neighborFacePixel(hp, fp, d)
+				if !ok {
+					continue
+				}
+				if _, found := seen[neigh]; !found {
+					seen[neigh] = struct{}{}
+					next = append(next, neigh)
+				}
+			}
+		}
+		frontier = next
+	}
+}
+
+// The largest angular distance from the center of the pixel to any of its vertices. The pixel edges bulge
+// very slightly beyond their vertices in places, so this is padded by a small relative margin.
+func facePixelRadius(hp Healpix, fp FacePixel) float64 {
+	center := fp.ToSphereCoordinate(hp).ToVector()
+	radius := 0.0
+	for _, vertex := range fp.vertices(hp) {
+		radius = max(radius, angleBetween(center, vertex.ToVector()))
+	}
+	return radius * 1.01
+}
+
+// A convex spherical polygon, described by the inward pointing normals of the great circles of its edges.
+type convexPolygon struct {
+	edges   []greatArc
+	normals []Vector
+	center  Vector  // center of a cap enclosing the polygon
+	radius  float64 // radius of a cap enclosing the polygon
+}
+
+func newConvexPolygon(hp Healpix, vertices []Where) convexPolygon {
+	if len(vertices) < 3 {
+		panic("healpix: a polygon needs at least three vertices")
+	}
+	vs := make([]Vector, len(vertices))
+	sum := Vector{}
+	for i, v := range vertices {
+		vs[i] = toVector(hp, v)
+		sum = sum.add(vs[i])
+	}
+	center := sum.Normalize()
+
+	poly := convexPolygon{center: center}
+	// flip the normals if the vertices wind clockwise, so they always point inward
+	sign := 1.0
+	if center.dot(vs[0].cross(vs[1])) < 0 {
+		sign = -1
+	}
+	for i, v := range vs {
+		w := vs[(i+1)%len(vs)]
+		poly.edges = append(poly.edges, newGreatArc(v, w))
+		poly.normals = append(poly.normals, v.cross(w).scale(sign))
+		poly.radius = max(poly.radius, angleBetween(center, v))
+	}
+	// a polygon is convex when every vertex lies on the inner side of every edge, which also rules out edges
+	// that cross each other
+	for i, n := range poly.normals {
+		for j, v := range vs {
+			if v.dot(n) < -1e-12*n.Length() {
+				panic(fmt.Sprintf("healpix: polygon is not convex, vertex %d lies outside the edge from vertex %d", j, i))
+			}
+		}
+	}
+	return poly
+}
+
+// Whether the unit vector lies inside (or on the boundary of) the polygon.
+func (p convexPolygon) contains(v Vector) bool {
+	for _, n := range p.normals {
+		if v.dot(n) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// The angular distance from the unit vector to the nearest edge of the polygon.
+func (p convexPolygon) boundaryDistance(v Vector) float64 {
+	distance := math.Inf(1)
+	for _, e := range p.edges {
+		distance = min(distance, e.distance(v))
+	}
+	return distance
+}
@@ -1,6 +1,8 @@
 package healpix
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
 	"golang.org/x/exp/slices"
@@ -13,37 +15,37 @@ func TestNeighbors(t *testing.T) {
 		pixel     int
 		neighbors []uint
 	}{
-		{"Order 0: pixel 0 = {8,5,1,4,1,3,3}", 0, 0, []uint{8, 5, 1, 4, 1, 3, 3}},
-		{"Order 0: pixel 1 = {9,6,2,5,2,0,0}", 0, 1, []uint{9, 6, 2, 5, 2, 0, 0}},
+		{"Order 0: pixel 0 = {8,5,4,1,3,2}", 0, 0, []uint{8, 5, 4, 1, 3, 2}},
+		{"Order 0: pixel 1 = {9,6,5,2,0,3}", 0, 1, []uint{9, 6, 5, 2, 0, 3}},
 		{"Order 0: pixel 5 = {9,6,8,1,4,0}", 0, 5, []uint{9, 6, 8, 1, 4, 0}},
 		{"Order 0: pixel 6 = {10,7,9,2,5,1}", 0, 6, []uint{10, 7, 9, 2, 5, 1}},
-		{"Order 0: pixel 10 = {11,11,9,7,9,6,2}", 0, 10, []uint{11, 11, 9, 7, 9, 6, 2}},
-		{"Order 0: pixel 11 = {8,8,10,4,10,7,3}", 0, 11, []uint{8, 8, 10, 4, 10, 7, 3}},
+		{"Order 0: pixel 10 = {8,11,9,7,6,2}", 0, 10, []uint{8, 11, 9, 7, 6, 2}},
+		{"Order 0: pixel 11 = {9,8,10,4,7,3}", 0, 11, []uint{9, 8, 10, 4, 7, 3}},
 
 		{"Order 1: pixel 0 = {35,22,23,17,1,19,2,3}", 1, 0, []uint{35, 22, 23, 17, 1, 19, 2, 3}},
-		{"Order 1: pixel 1 = {22,23,6,0,6,2,3,7}", 1, 1, []uint{22, 23, 6, 0, 6, 2, 3, 7}},
-		{"Order 1: pixel 2 = {17,0,1,19,3,13,13,15}", 1, 2, []uint{17, 0, 1, 19, 3, 13, 13, 15}},
-		{"Order 1: pixel 3 = {0,1,6,2,7,13,15}", 1, 3, []uint{0, 1, 6, 2, 7, 13, 15}},
+		{"Order 1: pixel 1 = {22,23,0,6,2,3,7}", 1, 1, []uint{22, 23, 0, 6, 2, 3, 7}},
+		{"Order 1: pixel 2 = {17,0,1,19,3,13,15}", 1, 2, []uint{17, 0, 1, 19, 3, 13, 15}},
+		{"Order 1: pixel 3 = {0,1,6,2,7,13,15,11}", 1, 3, []uint{0, 1, 6, 2, 7, 13, 15, 11}},
 		{"Order 1: pixel 22 = {33,20,21,35,23,17,0,1}", 1, 22, []uint{33, 20, 21, 35, 23, 17, 0, 1}},
 		{"Order 1: pixel 23 = {20,21,4,22,6,0,1}", 1, 23, []uint{20, 21, 4, 22, 6, 0, 1}},
 		{"Order 1: pixel 21 = {38,39,26,20,4,22,23,6}", 1, 21, []uint{38, 39, 26, 20, 4, 22, 23, 6}},
 		{"Order 1: pixel 20 = {38,39,33,21,35,22,23}", 1, 20, []uint{38, 39, 33, 21, 35, 22, 23}},
 		{"Order 1: pixel 35 = {32,33,20,34,22,16,17,0}", 1, 35, []uint{32, 33, 20, 34, 22, 16, 17, 0}},
-		{"Order 1: pixel 33 = {36,38,38,32,20,34,35,22}", 1, 33, []uint{36, 38, 38, 32, 20, 34, 35, 22}},
-		{"Order 1: pixel 32 = {36,38,44,33,45,34,35}", 1, 32, []uint{36, 38, 44, 33, 45, 34, 35}},
-		{"Order 1: pixel 34 = {44,32,33,45,35,45,16,17}", 1, 34, []uint{44, 32, 33, 45, 35, 45, 16, 17}},
+		{"Order 1: pixel 33 = {36,38,32,20,34,35,22}", 1, 33, []uint{36, 38, 32, 20, 34, 35, 22}},
+		{"Order 1: pixel 32 = {40,36,38,44,33,45,34,35}", 1, 32, []uint{40, 36, 38, 44, 33, 45, 34, 35}},
+		{"Order 1: pixel 34 = {44,32,33,45,35,16,17}", 1, 34, []uint{44, 32, 33, 45, 35, 16, 17}},
 
 		{"Order 2: pixel 0 = {143,90,91,69,1,71,2,3}", 2, 0, []uint{143, 90, 91, 69, 1, 71, 2, 3}},
 		{"Order 2: pixel 3 = {0,1,4,2,6,8,9,12}", 2, 3, []uint{0, 1, 4, 2, 6, 8, 9, 12}},
 		{"Order 2: pixel 12 = {3,6,7,9,13,11,14,15}", 2, 12, []uint{3, 6, 7, 9, 13, 11, 14, 15}},
-		{"Order 2: pixel 15 = {12,13,30,14,31,61,63}", 2, 15, []uint{12, 13, 30, 14, 31, 61, 63}},
-		{"Order 2: pixel 186 = {164,184,185,165,187,165,112,113}", 2, 186, []uint{164, 184, 185, 165, 187, 165, 112, 113}},
+		{"Order 2: pixel 15 = {12,13,30,14,31,61,63,47}", 2, 15, []uint{12, 13, 30, 14, 31, 61, 63, 47}},
+		{"Order 2: pixel 186 = {164,184,185,165,187,112,113}", 2, 186, []uint{164, 184, 185, 165, 187, 112, 113}},
 		{"Order 2: pixel 184 = {161,178,179,164,185,165,186,187}", 2, 184, []uint{161, 178, 179, 164, 185, 165, 186, 187}},
 		{"Order 2: pixel 178 = {160,176,177,161,179,164,184,185}", 2, 178, []uint{160, 176, 177, 161, 179, 164, 184, 185}},
-		{"Order 2: pixel 176 = {128,130,160,177,161,178,179}", 2, 176, []uint{128, 130, 160, 177, 161, 178, 179}},
+		{"Order 2: pixel 176 = {144,128,130,160,177,161,178,179}", 2, 176, []uint{144, 128, 130, 160, 177, 161, 178, 179}},
 		{"Order 2: pixel 177 = {128,130,136,176,180,178,179,182}", 2, 177, []uint{128, 130, 136, 176, 180, 178, 179, 182}},
 		{"Order 2: pixel 180 = {130,136,138,177,181,179,182,183}", 2, 180, []uint{130, 136, 138, 177, 181, 179, 182, 183}},
-		{"Order 2: pixel 181 = {136,138,138,180,64,182,183,66}", 2, 181, []uint{136, 138, 138, 180, 64, 182, 183, 66}},
+		{"Order 2: pixel 181 = {136,138,180,64,182,183,66}", 2, 181, []uint{136, 138, 180, 64, 182, 183, 66}},
 	}
 
 	for _, tc := range testCases {
@@ -55,3 +57,335 @@ func TestNeighbors(t *testing.T) {
 		})
 	}
 }
+
+func TestNeighborsInto(t *testing.T) {
+	testCases := []struct {
+		name      string
+		order     int
+		pixel     NestPixel
+		neighbors [8]int64
+	}{
+		{"Order 0: pixel 0 has no east or west neighbor", 0, 0, [8]int64{8, 5, -1, 1, 2, 3, -1, 4}},
+		{"Order 0: pixel 4 has no south or north neighbor", 0, 4, [8]int64{-1, 8, 5, 0, -1, 3, 7, 11}},
+		{"Order 1: pixel 3 has a northern neighbor across the pole", 1, 3, [8]int64{0, 1, 6, 7, 11, 15, 13, 2}},
+		{"Order 1: pixel 19 has no northern neighbor", 1, 19, [8]int64{16, 17, 0, 2, -1, 13, 12, 18}},
+		{"Order 2: pixel 12 is inside the face", 2, 12, [8]int64{3, 6, 7, 13, 15, 14, 11, 9}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var neighbors [8]int64
+			NeighborsInto(New(NewHealpixOrder(tc.order)), tc.pixel, NestScheme, &neighbors)
+			if neighbors != tc.neighbors {
+				t.Errorf("Pixel %v for order %v expected neighbors %v, got %v instead", tc.pixel, tc.order, tc.neighbors, neighbors)
+			}
+		})
+	}
+}
+
+func TestNeighborsSymmetricWithoutDuplicates(t *testing.T) {
+	for order := 0; order <= 4; order++ {
+		hp := New(NewHealpixOrder(order))
+		for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+			for p := uint(0); p < hp.Pixels(); p++ {
+				var pixel Where = NestPixel(p)
+				if scheme == RingScheme {
+					pixel = RingPixel(p)
+				}
+				var neighbors [8]int64
+				NeighborsInto(hp, pixel, scheme, &neighbors)
+				seen := map[int64]bool{}
+				for d, n := range neighbors {
+					if n < 0 {
+						continue
+					}
+					if seen[n] || uint(n) == p {
+						t.Fatalf("Order %d: pixel %v has duplicate neighbor %v in %v", order, p, n, neighbors)
+					}
+					seen[n] = true
+
+					var back [8]int64
+					var neighbor Where = NestPixel(n)
+					if scheme == RingScheme {
+						neighbor = RingPixel(n)
+					}
+					NeighborsInto(hp, neighbor, scheme, &back)
+					if !slices.Contains(back[:], int64(p)) {
+						t.Fatalf("Order %d: pixel %v has neighbor %v to the %v, but not the reverse", order, p, n, Direction(d))
+					}
+					if Neighbor(hp, scheme, pixel, directionOffsets[d][0], directionOffsets[d][1]) != uint(n) {
+						t.Fatalf("Order %d: Neighbor of pixel %v to the %v disagrees with NeighborsInto", order, p, Direction(d))
+					}
+				}
+				if order > 0 && (len(seen) < 7 || len(seen) > 8) {
+					t.Fatalf("Order %d: pixel %v expected 7 or 8 neighbors, got %v", order, p, neighbors)
+				}
+			}
+		}
+	}
+}
+
+func TestNeighborsIntoDoesNotAllocate(t *testing.T) {
+	hp := New(NewHealpixOrder(10))
+	var neighbors [8]int64
+	allocs := testing.AllocsPerRun(100, func() {
+		NeighborsInto(hp, NestPixel(12345), NestScheme, &neighbors)
+	})
+	if allocs != 0 {
+		t.Errorf("NeighborsInto expected no allocations, got %v instead", allocs)
+	}
+}
+
+func TestQueryPolylineSingleSegment(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	// passes just north of the southern vertex of face 0, between faces 4 and 5
+	points := []Where{NewLatLonCoordinate(0.1, 0.2), NewLatLonCoordinate(0.1, 1.2)}
+	crossings := QueryPolyline(hp, points, NestScheme)
+	pixels := []uint{}
+	for _, c := range crossings {
+		pixels = append(pixels, c.Pixel())
+	}
+	expected := []uint{17, 0, 22}
+	if !slices.Equal(pixels, expected) {
+		t.Errorf("Expected crossed pixels %v, got %v instead", expected, pixels)
+	}
+}
+
+func TestQueryPolylineProperties(t *testing.T) {
+	hp := New(NewHealpixOrder(5))
+	rng := rand.New(rand.NewSource(33))
+	for trial := 0; trial < 20; trial++ {
+		points := []Where{}
+		for i := 0; i < 4; i++ {
+			points = append(points, NewLatLonCoordinate(math.Asin(2*rng.Float64()-1), 2*math.Pi*rng.Float64()))
+		}
+		crossings := QueryPolyline(hp, points, NestScheme)
+		if crossings[0].Pixel() != uint(points[0].ToNestPixel(hp)) {
+			t.Fatalf("First crossing %v expected to be the pixel of the first point %v", crossings[0].Pixel(), points[0].ToNestPixel(hp))
+		}
+		if crossings[len(crossings)-1].Pixel() != uint(points[3].ToNestPixel(hp)) {
+			t.Fatalf("Last crossing %v expected to be the pixel of the last point %v", crossings[len(crossings)-1].Pixel(), points[3].ToNestPixel(hp))
+		}
+
+		crossed := map[uint]bool{}
+		for i, c := range crossings {
+			crossed[c.Pixel()] = true
+			if i == 0 {
+				continue
+			}
+			prev := crossings[i-1]
+			if prev.Pixel() == c.Pixel() {
+				t.Fatalf("Pixel %v repeated in consecutive crossings", c.Pixel())
+			}
+			var neighbors [8]int64
+			NeighborsInto(hp, NestPixel(prev.Pixel()), NestScheme, &neighbors)
+			if !slices.Contains(neighbors[:], int64(c.Pixel())) {
+				t.Fatalf("Consecutive crossings %v and %v expected to be neighbors", prev.Pixel(), c.Pixel())
+			}
+			if angleBetween(prev.Exit().ToVector(), c.Entry().ToVector()) > 1e-12 {
+				t.Fatalf("Exit %v of %v expected to match entry %v of %v", prev.Exit(), prev.Pixel(), c.Entry(), c.Pixel())
+			}
+		}
+
+		// dense sampling can miss clipped corners, but should never find a pixel the traversal did not
+		for _, arc := range polylineArcs(hp, points) {
+			for s := 0.0; s <= arc.length; s += hp.AngularResolution() / 50 {
+				if p := uint(arc.at(s).ToNestPixel(hp)); !crossed[p] {
+					t.Fatalf("Sampled pixel %v along the polyline was not crossed", p)
+				}
+			}
+		}
+	}
+}
+
+func TestQueryPolylineCorridor(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	points := []Where{NewLatLonCoordinate(0.3, 0.1), NewLatLonCoordinate(-0.2, 1.0), NewLatLonCoordinate(0.9, 2.0)}
+	arcs := polylineArcs(hp, points)
+
+	// the distance from each pixel center to the path, and the least distance from points densely sampled
+	// along its boundary, which is no less than the exact distance from the pixel
+	centerDistance := func(p NestPixel) float64 {
+		distance := math.Inf(1)
+		for _, arc := range arcs {
+			distance = min(distance, arc.distance(p.ToSphereCoordinate(hp).ToVector()))
+		}
+		return distance
+	}
+	boundaryDistance := func(p NestPixel) float64 {
+		fp := p.ToFacePixel(hp)
+		nside := float64(hp.FaceSidePixels())
+		x0, y0 := float64(fp.x)/nside, float64(fp.y)/nside
+		distance := math.Inf(1)
+		const samples = 64
+		for i := 0; i < samples; i++ {
+			t := float64(i) / (samples * nside)
+			for _, fc := range [4]FaceCoordinate{
+				{fp.face, x0 + t, y0},
+				{fp.face, x0 + 1/nside, y0 + t},
+				{fp.face, x0 + 1/nside - t, y0 + 1/nside},
+				{fp.face, x0, y0 + 1/nside - t},
+			} {
+				for _, arc := range arcs {
+					distance = min(distance, arc.distance(fc.ToVector()))
+				}
+			}
+		}
+		return distance
+	}
+
+	crossed := []uint{}
+	for _, c := range QueryPolyline(hp, points, NestScheme) {
+		if !slices.Contains(crossed, c.Pixel()) {
+			crossed = append(crossed, c.Pixel())
+		}
+	}
+	for _, halfWidth := range []float64{0, 0.5 * hp.AngularResolution(), 3 * hp.AngularResolution()} {
+		corridor := QueryPolylineCorridor(hp, points, halfWidth, NestScheme)
+		if len(corridor) < len(crossed) || !slices.Equal(corridor[:len(crossed)], crossed) {
+			t.Errorf("Half width %v: expected the corridor to start with the crossed pixels %v", halfWidth, crossed)
+		}
+		inCorridor := map[uint]bool{}
+		for _, p := range corridor {
+			if inCorridor[p] {
+				t.Fatalf("Half width %v: pixel %v repeated in corridor", halfWidth, p)
+			}
+			inCorridor[p] = true
+		}
+		for p := NestPixel(0); p < NestPixel(hp.Pixels()); p++ {
+			if distance := boundaryDistance(p); distance <= halfWidth && !inCorridor[uint(p)] {
+				t.Errorf("Half width %v: pixel %v with boundary %v from the path expected in the corridor", halfWidth, p, distance)
+			}
+			if distance := centerDistance(p); distance > halfWidth+hp.MaxPixelRadius() && inCorridor[uint(p)] {
+				t.Errorf("Half width %v: pixel %v with center %v from the path expected outside the corridor", halfWidth, p, distance)
+			}
+		}
+	}
+}
+
+func TestQueryDisc(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	rng := rand.New(rand.NewSource(36))
+	for trial := 0; trial < 10; trial++ {
+		center := NewLatLonCoordinate(math.Asin(2*rng.Float64()-1), 2*math.Pi*rng.Float64())
+		radius := 0.5 * rng.Float64()
+		exact := map[uint]bool{}
+		for _, p := range QueryDisc(hp, center, radius, RingScheme, false) {
+			exact[p] = true
+		}
+		inclusive := map[uint]bool{}
+		for _, p := range QueryDisc(hp, center, radius, RingScheme, true) {
+			inclusive[p] = true
+		}
+		for p := RingPixel(0); p < RingPixel(hp.Pixels()); p++ {
+			distance := angleBetween(center.ToVector(), p.ToSphereCoordinate(hp).ToVector())
+			if (distance <= radius) != exact[uint(p)] {
+				t.Errorf("Pixel %v at distance %v expected in disc of radius %v: %v", p, distance, radius, distance <= radius)
+			}
+			if exact[uint(p)] && !inclusive[uint(p)] {
+				t.Errorf("Pixel %v expected in inclusive disc", p)
+			}
+		}
+	}
+}
+
+func TestQueryStrip(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	testCases := []struct {
+		name   string
+		colat1 float64
+		colat2 float64
+	}{
+		{"North polar cap", 0, 0.3},
+		{"Across the equator", 1.2, 1.9},
+		{"Reversed bounds", 2.5, 2.1},
+		{"Thinner than a ring", 1.5, 1.51},
+		{"Whole sphere", 0, math.Pi},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			north, south := min(tc.colat1, tc.colat2), max(tc.colat1, tc.colat2)
+			found := map[uint]bool{}
+			for _, p := range QueryStrip(hp, tc.colat1, tc.colat2, NestScheme, false) {
+				found[p] = true
+			}
+			inclusive := map[uint]bool{}
+			for _, p := range QueryStrip(hp, tc.colat1, tc.colat2, NestScheme, true) {
+				inclusive[p] = true
+			}
+			for p := NestPixel(0); p < NestPixel(hp.Pixels()); p++ {
+				colat := p.ToSphereCoordinate(hp).Colatitude()
+				if inside := colat >= north && colat <= south; inside != found[uint(p)] {
+					t.Errorf("Pixel %v at colatitude %v expected in strip: %v", p, colat, inside)
+				}
+				vertices := p.ToFacePixel(hp).Vertices(hp)
+				top, bottom := vertices[0].Colatitude(), vertices[2].Colatitude()
+				if overlaps := top <= south+1e-12 && bottom >= north-1e-12; overlaps != inclusive[uint(p)] {
+					t.Errorf("Pixel %v spanning colatitudes %v - %v expected in inclusive strip: %v", p, top, bottom, overlaps)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryPolygon(t *testing.T) {
+	hp := New(NewHealpixOrder(4))
+	vertices := []Where{
+		NewLatLonCoordinate(0.8, 0.2),
+		NewLatLonCoordinate(0.1, 0.1),
+		NewLatLonCoordinate(0.2, 1.1),
+	}
+	for _, reversed := range []bool{false, true} {
+		vs := slices.Clone(vertices)
+		if reversed {
+			slices.Reverse(vs)
+		}
+		poly := newConvexPolygon(hp, vs)
+		found := map[uint]bool{}
+		for _, p := range QueryPolygon(hp, vs, NestScheme, false) {
+			found[p] = true
+		}
+		inclusive := map[uint]bool{}
+		for _, p := range QueryPolygon(hp, vs, NestScheme, true) {
+			inclusive[p] = true
+		}
+		if len(found) == 0 || len(inclusive) <= len(found) {
+			t.Errorf("Expected a non-empty polygon with a larger inclusive version, got %v and %v", len(found), len(inclusive))
+		}
+		for p := NestPixel(0); p < NestPixel(hp.Pixels()); p++ {
+			inside := poly.contains(p.ToSphereCoordinate(hp).ToVector())
+			if inside != found[uint(p)] {
+				t.Errorf("Pixel %v expected in polygon: %v", p, inside)
+			}
+		}
+	}
+}
+
+func TestQueryPolygonConvexity(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	testCases := []struct {
+		name     string
+		vertices [][2]float64
+		valid    bool
+	}{
+		{"triangle", [][2]float64{{0, 0}, {0, 0.4}, {0.4, 0.2}}, true},
+		{"square with a vertex on an edge", [][2]float64{{0, 0}, {0, 0.2}, {0, 0.4}, {0.4, 0.4}, {0.4, 0}}, true},
+		{"too few vertices", [][2]float64{{0, 0}, {0, 0.4}}, false},
+		{"concave arrow", [][2]float64{{0, 0}, {0.2, 0.2}, {0, 0.4}, {0.4, 0.2}}, false},
+		{"crossing edges", [][2]float64{{0, 0}, {0.4, 0.4}, {0.4, 0}, {0, 0.4}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vertices := []Where{}
+			for _, v := range tc.vertices {
+				vertices = append(vertices, NewLatLonCoordinate(v[0], v[1]))
+			}
+			defer func() {
+				if panicked := recover() != nil; panicked == tc.valid {
+					t.Errorf("Expected polygon valid: %v, but panicked: %v", tc.valid, panicked)
+				}
+			}()
+			QueryPolygon(hp, vertices, NestScheme, false)
+		})
+	}
+}
@@ -18,7 +18,7 @@ type Ring struct {
 // the north pole at 0, and increment southward by 1 until reaching the south pole. The largest ring index possible
 // for a given HEALPix map is base.Rings() - 1. Panics if the ring index is invalid.
 func NewRing(base Healpix, index int) Ring {
-	if index < 0 && index > base.Rings() {
+	if index < 0 || index >= base.Rings() {
 		panic("healpix: ring index was invalid during ring creation")
 	}
 
@@ -66,28 +66,118 @@ func (r Ring) Pixels() int {
 	return 4 * r.base.FaceSidePixels()
 }
 
-// The latitude where the center of each pixel in the ring lies, in radians.
-func (r Ring) Colatitude() float64 {
+// The cosine of the colatitude where the center of each pixel in the ring lies, computed exactly from the
+// ring index.
+func (r Ring) Z() float64 {
 	if r.northIndex < r.base.FaceSidePixels() {
 		z := 1 - float64(r.northIndex+1)*float64(r.northIndex+1)/(float64(3)*float64(r.base.FacePixels()))
 		if r.northIndex != r.index {
-			return math.Pi - math.Acos(z)
-		} else {
-			return math.Acos(z)
+			return -z
 		}
-	} else {
-		z := float64(4)/float64(3) - float64(2*(r.index+1))/float64(3*r.base.FaceSidePixels())
-		return math.Acos(z)
+		return z
+	}
+	return float64(4)/float64(3) - float64(2*(r.index+1))/float64(3*r.base.FaceSidePixels())
+}
+
+// The sine of the colatitude where the center of each pixel in the ring lies. Computed without going through
+// Z() in the polar caps, so it keeps full precision for the rings nearest the poles.
+func (r Ring) sinColatitude() float64 {
+	if r.northIndex < r.base.FaceSidePixels() {
+		tmp := float64(r.northIndex+1) * float64(r.northIndex+1) / (float64(3) * float64(r.base.FacePixels()))
+		return math.Sqrt(tmp * (2 - tmp))
 	}
+	z := r.Z()
+	return math.Sqrt((1 - z) * (1 + z))
 }
 
-// The colatitutde where the center of each pixel in the ring lies, in radians.
+// The colatitude where the center of each pixel in the ring lies, in radians.
+func (r Ring) Colatitude() float64 {
+	if r.northIndex < r.base.FaceSidePixels() && r.northIndex != r.index {
+		return math.Pi - math.Acos(-r.Z())
+	}
+	return math.Acos(r.Z())
+}
+
+// The latitude where the center of each pixel in the ring lies, in radians.
 func (r Ring) Latitude() float64 {
 	return math.Pi/2 - r.Colatitude()
 }
 
+// The smallest colatitude reached by any pixel in the ring, in radians. The northern vertices of the pixels
+// lie on the centers of the ring to the north, or at the north pole for the first ring.
+func (r Ring) NorthColatitude() float64 {
+	if r.index == 0 {
+		return 0
+	}
+	return NewRing(r.base, r.index-1).Colatitude()
+}
+
+// The largest colatitude reached by any pixel in the ring, in radians. The southern vertices of the pixels
+// lie on the centers of the ring to the south, or at the south pole for the last ring.
+func (r Ring) SouthColatitude() float64 {
+	if r.index == r.base.Rings()-1 {
+		return math.Pi
+	}
+	return NewRing(r.base, r.index+1).Colatitude()
+}
+
+// The longitude of the center of the first pixel in the ring, in radians.
+func (r Ring) Phi0() float64 {
+	if r.IsOffset() {
+		return math.Pi / float64(r.Pixels())
+	}
+	return 0
+}
+
+// The longitude of the center of the pixel at the given position in the ring, in radians.
+func (r Ring) LongitudeOf(pixelInRing int) float64 {
+	return r.Phi0() + 2*math.Pi*float64(pixelInRing)/float64(r.Pixels())
+}
+
+// The position in the ring of the pixel whose center is nearest the given longitude in radians, which is the
+// pixel containing that longitude along the line through the ring's pixel centers. Longitudes outside 0 - 2Pi
+// wrap around the ring.
+func (r Ring) PixelAtLongitude(lon float64) int {
+	pixels := r.Pixels()
+	offset := (lon - r.Phi0()) * float64(pixels) / (2 * math.Pi)
+	i := int(math.Floor(offset+0.5)) % pixels
+	if i < 0 {
+		i += pixels
+	}
+	return i
+}
+
+// Returns the index of the ring whose pixel centers are nearest in colatitude to the given colatitude in
+// radians. Colatitudes outside 0 - Pi are clamped to the poles.
+func RingForColatitude(hp Healpix, colatitude float64) int {
+	colatitude = max(0, min(math.Pi, colatitude))
+	// estimate the continuous ring number, counting from 1 at the first ring
+	nside := float64(hp.FaceSidePixels())
+	z := math.Cos(colatitude)
+	var estimate float64
+	if z > 2.0/3.0 {
+		estimate = nside * math.Sqrt(6) * math.Sin(colatitude/2)
+	} else if z < -2.0/3.0 {
+		estimate = 4*nside - nside*math.Sqrt(6)*math.Cos(colatitude/2)
+	} else {
+		estimate = nside * (2 - 1.5*z)
+	}
+	// the estimate is within a ring of the nearest, so settle it by comparing the candidates around it
+	best := -1
+	bestDistance := math.Inf(1)
+	for ring := int(math.Round(estimate)) - 2; ring <= int(math.Round(estimate)); ring++ {
+		if ring < 0 || ring >= hp.Rings() {
+			continue
+		}
+		if d := math.Abs(NewRing(hp, ring).Colatitude() - colatitude); d < bestDistance {
+			best, bestDistance = ring, d
+		}
+	}
+	return best
+}
+
 // True if the first pixel of the ring has it's center not on 0 longitude, but just slightly off it.
-// False if the first pixel of the ring has it's center on 0 longitude.
+// False if the first pixel of the ring has it's center on 0 longitude. Also known as 'shifted' in healpy.
 func (r Ring) IsOffset() bool {
 	if r.northIndex < r.base.FaceSidePixels() {
 		return true
@@ -95,3 +185,144 @@ func (r Ring) IsOffset() bool {
 	// only odd rings have their first pixel center on 0 longitude
 	return ((r.northIndex - r.base.FaceSidePixels()) & 1) != 0
 }
+
+// Everything about the ring containing a pixel that ring based algorithms need, gathered at once in the
+// manner of healpy's ring info: where the ring starts and how many pixels it holds, where its pixel centers
+// lie, and where the pixel sits in it.
+type RingInfo struct {
+	ring          int
+	pixelInRing   int
+	firstIndex    uint
+	pixels        int
+	z             float64
+	sinColatitude float64
+	offset        bool
+}
+
+// Get the ring information for the ring containing the given position.
+func NewRingInfo(hp Healpix, where Where) RingInfo {
+	coord := where.ToRingCoordinate(hp)
+	ring := NewRing(hp, coord.ring)
+	return RingInfo{
+		coord.ring,
+		coord.pixelInRing,
+		ring.FirstIndex(),
+		ring.Pixels(),
+		ring.Z(),
+		ring.sinColatitude(),
+		ring.IsOffset(),
+	}
+}
+
+// The index of the ring, from 0 at the north pole.
+func (i RingInfo) Ring() int {
+	return i.ring
+}
+
+// The position of the pixel within the ring.
+func (i RingInfo) PixelInRing() int {
+	return i.pixelInRing
+}
+
+// The Ring scheme pixel number of the first pixel in the ring.
+func (i RingInfo) FirstIndex() uint {
+	return i.firstIndex
+}
+
+// The number of pixels in the ring.
+func (i RingInfo) Pixels() int {
+	return i.pixels
+}
+
+// The cosine of the colatitude of the pixel centers in the ring.
+func (i RingInfo) Z() float64 {
+	return i.z
+}
+
+// The sine of the colatitude of the pixel centers in the ring, accurate even for the rings nearest the poles.
+func (i RingInfo) SinColatitude() float64 {
+	return i.sinColatitude
+}
+
+// Whether the center of the first pixel of the ring is offset from 0 longitude.
+func (i RingInfo) IsOffset() bool {
+	return i.offset
+}
+
+// Describes the range of shapes of a group of pixels. Edge lengths are the distances between adjacent
+// vertices of a pixel, and the aspect ratio of a pixel is the distance between its north and south vertices
+// divided by the distance between its east and west vertices, or the inverse if that is larger, so that it
+// is always at least 1.
+type PixelShape struct {
+	minEdge   float64
+	maxEdge   float64
+	minAspect float64
+	maxAspect float64
+}
+
+// The shortest edge of any of the pixels.
+func (s PixelShape) MinEdge() float64 {
+	return s.minEdge
+}
+
+// The longest edge of any of the pixels.
+func (s PixelShape) MaxEdge() float64 {
+	return s.maxEdge
+}
+
+// The aspect ratio of the least elongated of the pixels.
+func (s PixelShape) MinAspect() float64 {
+	return s.minAspect
+}
+
+// The aspect ratio of the most elongated of the pixels.
+func (s PixelShape) MaxAspect() float64 {
+	return s.maxAspect
+}
+
+func (s PixelShape) merge(other PixelShape) PixelShape {
+	return PixelShape{
+		min(s.minEdge, other.minEdge),
+		max(s.maxEdge, other.maxEdge),
+		min(s.minAspect, other.minAspect),
+		max(s.maxAspect, other.maxAspect),
+	}
+}
+
+// Measure the edges and aspect ratio of a single pixel from its vertices.
+func pixelShape(vertices [4]SphereCoordinate, distance func(SphereCoordinate, SphereCoordinate) float64) PixelShape {
+	shape := PixelShape{math.Inf(1), 0, 0, 0}
+	for i := range vertices {
+		edge := distance(vertices[i], vertices[(i+1)%4])
+		shape.minEdge = min(shape.minEdge, edge)
+		shape.maxEdge = max(shape.maxEdge, edge)
+	}
+	northSouth := distance(vertices[0], vertices[2])
+	westEast := distance(vertices[1], vertices[3])
+	aspect := max(northSouth, westEast) / min(northSouth, westEast)
+	shape.minAspect = aspect
+	shape.maxAspect = aspect
+	return shape
+}
+
+// The range of edge lengths and aspect ratios of the pixels in this ring, measured as angles in radians.
+func (r Ring) PixelShape() PixelShape {
+	return r.PixelShapeWith(SphereCoordinate.AngularDistance)
+}
+
+// The range of edge lengths and aspect ratios of the pixels in this ring, measured with the given distance
+// function between two positions.
+func (r Ring) PixelShapeWith(distance func(SphereCoordinate, SphereCoordinate) float64) PixelShape {
+	// each ring repeats itself four times around the sphere, and away from the polar caps all the
+	// pixels of a ring are the same shape
+	count := r.Pixels() / 4
+	if r.northIndex > r.base.FaceSidePixels() {
+		count = 1
+	}
+	shape := pixelShape(NewRingCoordinate(r.index, 0).ToFacePixel(r.base).Vertices(r.base), distance)
+	for i := 1; i < count; i++ {
+		vertices := NewRingCoordinate(r.index, i).ToFacePixel(r.base).Vertices(r.base)
+		shape = shape.merge(pixelShape(vertices, distance))
+	}
+	return shape
+}
@@ -174,3 +174,149 @@ func TestRingLatitude(t *testing.T) {
 		})
 	}
 }
+
+func TestRingPixelShapeMatchesAllPixels(t *testing.T) {
+	for order := 0; order <= 4; order++ {
+		hp := New(NewHealpixOrder(order))
+		for ringId := 0; ringId < hp.Rings(); ringId++ {
+			ring := NewRing(hp, ringId)
+			expected := PixelShape{math.Inf(1), 0, math.Inf(1), 0}
+			for i := 0; i < ring.Pixels(); i++ {
+				vertices := NewRingCoordinate(ringId, i).ToFacePixel(hp).Vertices(hp)
+				expected = expected.merge(pixelShape(vertices, SphereCoordinate.AngularDistance))
+			}
+			r := ring.PixelShape()
+			if !withinTolerance(r.MinEdge(), expected.MinEdge(), 1e-9) || !withinTolerance(r.MaxEdge(), expected.MaxEdge(), 1e-9) ||
+				!withinTolerance(r.MinAspect(), expected.MinAspect(), 1e-9) || !withinTolerance(r.MaxAspect(), expected.MaxAspect(), 1e-9) {
+				t.Errorf("Order %d ring %d: expected %v, got %v instead", order, ringId, expected, r)
+			}
+		}
+	}
+}
+
+func TestNewRingInvalidIndexPanics(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	for _, index := range []int{-1, hp.Rings()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected ring index %d to panic", index)
+				}
+			}()
+			NewRing(hp, index)
+		}()
+	}
+}
+
+func TestRingPhi0(t *testing.T) {
+	testCases := []struct {
+		name   string
+		order  HealpixOrder
+		ringId int
+		phi0   float64
+	}{
+		{"Order 0: ring 0", 0, 0, math.Pi / 4},
+		{"Order 0: ring 1", 0, 1, 0},
+		{"Order 0: ring 2", 0, 2, math.Pi / 4},
+		{"Order 1: ring 1", 1, 1, math.Pi / 8},
+		{"Order 1: ring 2", 1, 2, 0},
+		{"Order 1: ring 3", 1, 3, math.Pi / 8},
+		{"Order 1: ring 6", 1, 6, math.Pi / 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ring := NewRing(New(tc.order), tc.ringId)
+			if !withinTolerance(ring.Phi0(), tc.phi0, 1e-12) {
+				t.Errorf("Expected %v, got %v instead", tc.phi0, ring.Phi0())
+			}
+		})
+	}
+}
+
+func TestRingLongitudeLookup(t *testing.T) {
+	for order := 0; order <= 4; order++ {
+		hp := New(NewHealpixOrder(order))
+		for ringId := 0; ringId < hp.Rings(); ringId++ {
+			ring := NewRing(hp, ringId)
+			step := 2 * math.Pi / float64(ring.Pixels())
+			for i := 0; i < ring.Pixels(); i++ {
+				lon := ring.LongitudeOf(i)
+				center := NewRingCoordinate(ringId, i).ToFacePixel(hp).ToSphereCoordinate(hp)
+				if math.Abs(center.Longitude()-lon) > 1e-12 {
+					t.Fatalf("Order %d ring %d pixel %d: longitude expected %v, got %v instead", order, ringId, i, center.Longitude(), lon)
+				}
+				for _, shift := range []float64{-0.49, 0, 0.49, 2 * math.Pi / step} {
+					if r := ring.PixelAtLongitude(lon + shift*step); r != i {
+						t.Fatalf("Order %d ring %d: pixel at longitude %v expected %d, got %d instead", order, ringId, lon+shift*step, i, r)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRingZAndBounds(t *testing.T) {
+	for order := 0; order <= 4; order++ {
+		hp := New(NewHealpixOrder(order))
+		for ringId := 0; ringId < hp.Rings(); ringId++ {
+			ring := NewRing(hp, ringId)
+			if math.Abs(ring.Z()-math.Cos(ring.Colatitude())) > 1e-12 {
+				t.Fatalf("Order %d ring %d: z expected %v, got %v instead", order, ringId, math.Cos(ring.Colatitude()), ring.Z())
+			}
+			if math.Abs(ring.sinColatitude()-math.Sin(ring.Colatitude())) > 1e-12 {
+				t.Fatalf("Order %d ring %d: sin colatitude expected %v, got %v instead", order, ringId, math.Sin(ring.Colatitude()), ring.sinColatitude())
+			}
+			north, south := math.Pi, 0.0
+			for i := 0; i < ring.Pixels(); i++ {
+				vertices := NewRingCoordinate(ringId, i).ToFacePixel(hp).Vertices(hp)
+				north = min(north, vertices[0].Colatitude())
+				south = max(south, vertices[2].Colatitude())
+			}
+			if math.Abs(ring.NorthColatitude()-north) > 1e-12 || math.Abs(ring.SouthColatitude()-south) > 1e-12 {
+				t.Fatalf("Order %d ring %d: bounds expected %v - %v, got %v - %v instead", order, ringId, north, south, ring.NorthColatitude(), ring.SouthColatitude())
+			}
+		}
+	}
+}
+
+func TestRingForColatitude(t *testing.T) {
+	for order := 0; order <= 6; order++ {
+		hp := New(NewHealpixOrder(order))
+		for ringId := 0; ringId < hp.Rings(); ringId++ {
+			if r := RingForColatitude(hp, NewRing(hp, ringId).Colatitude()); r != ringId {
+				t.Fatalf("Order %d: ring for colatitude of ring %d expected %d, got %d instead", order, ringId, ringId, r)
+			}
+		}
+		for i := 0; i <= 1000; i++ {
+			colat := math.Pi * float64(i) / 1000
+			best, bestDistance := 0, math.Inf(1)
+			for ringId := 0; ringId < hp.Rings(); ringId++ {
+				if d := math.Abs(NewRing(hp, ringId).Colatitude() - colat); d < bestDistance {
+					best, bestDistance = ringId, d
+				}
+			}
+			if r := RingForColatitude(hp, colat); r != best {
+				t.Fatalf("Order %d: ring for colatitude %v expected %d, got %d instead", order, colat, best, r)
+			}
+		}
+	}
+}
+
+func TestRingInfo(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	for p := RingPixel(0); p < RingPixel(hp.Pixels()); p++ {
+		info := NewRingInfo(hp, p.ToNestPixel(hp))
+		coord := p.ToRingCoordinate(hp)
+		ring := NewRing(hp, coord.Ring())
+		if info.Ring() != coord.Ring() || info.PixelInRing() != coord.PixelInRing() {
+			t.Fatalf("Pixel %v: ring coordinate expected %v, got %v/%v instead", p, coord, info.Ring(), info.PixelInRing())
+		}
+		if info.FirstIndex()+uint(info.PixelInRing()) != uint(p) || info.Pixels() != ring.Pixels() || info.IsOffset() != ring.IsOffset() {
+			t.Fatalf("Pixel %v: ring info %v does not match ring", p, info)
+		}
+		center := p.ToSphereCoordinate(hp)
+		if math.Abs(info.Z()-math.Cos(center.Colatitude())) > 1e-12 || math.Abs(info.SinColatitude()-math.Sin(center.Colatitude())) > 1e-12 {
+			t.Fatalf("Pixel %v: z and sin colatitude %v/%v do not match center %v", p, info.Z(), info.SinColatitude(), center)
+		}
+	}
+}
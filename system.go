@@ -34,3 +34,37 @@ func IsValidNSide(test int) bool {
 	// middle test checks if test is a power of 2
 	return test > 0 && (test&(test-1) == 0) && test <= MaxNSide()
 }
+
+// Check whether the given number is a valid NUNIQ value for a UniquePixel: at least 4 (the first pixel at
+// order 0), and encoding an order no greater than the maximum supported order.
+func IsValidUniquePixel(test uint) bool {
+	return test >= 4 && (bits.Len(test)-3)/2 <= MaxOrder()
+}
+
+// The coarsest order whose HEALPix map has an angular resolution no larger than the given angle in
+// radians, so that its pixels are at least as fine as requested. Returns MaxOrder() if no supported order
+// is fine enough. Panics if the resolution is not positive.
+func OrderForResolution(radians float64) int {
+	if radians <= 0 {
+		panic("healpix: resolution must be positive")
+	}
+	order := 0
+	for order < MaxOrder() && New(HealpixOrder(order)).AngularResolution() > radians {
+		order++
+	}
+	return order
+}
+
+// The coarsest order whose HEALPix map has a pixel area no larger than the given area in steradians, so
+// that its pixels are at least as fine as requested. Returns MaxOrder() if no supported order is fine
+// enough. Panics if the area is not positive.
+func OrderForArea(steradians float64) int {
+	if steradians <= 0 {
+		panic("healpix: area must be positive")
+	}
+	order := 0
+	for order < MaxOrder() && New(HealpixOrder(order)).PixelArea() > steradians {
+		order++
+	}
+	return order
+}
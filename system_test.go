@@ -36,3 +36,44 @@ func TestIsValidNSide(t *testing.T) {
 		}
 	}
 }
+
+func TestOrderForResolution(t *testing.T) {
+	testCases := []struct {
+		name     string
+		radians  float64
+		expected int
+	}{
+		{"Coarser than order 0", 2, 0},
+		{"Exactly order 3", New(NewHealpixOrder(3)).AngularResolution(), 3},
+		{"Just finer than order 3", New(NewHealpixOrder(3)).AngularResolution() * 0.999, 4},
+		{"About 1 km on Earth", 1000.0 / 6371000, 13},
+		{"Finer than max order", 1e-30, MaxOrder()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if r := OrderForResolution(tc.radians); r != tc.expected {
+				t.Errorf("Expected %v, got %v instead", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestOrderForArea(t *testing.T) {
+	testCases := []struct {
+		name       string
+		steradians float64
+		expected   int
+	}{
+		{"Whole sphere", 4 * 3.15, 0},
+		{"Exactly order 5", New(NewHealpixOrder(5)).PixelArea(), 5},
+		{"Just finer than order 5", New(NewHealpixOrder(5)).PixelArea() * 0.999, 6},
+		{"Finer than max order", 1e-60, MaxOrder()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if r := OrderForArea(tc.steradians); r != tc.expected {
+				t.Errorf("Expected %v, got %v instead", tc.expected, r)
+			}
+		})
+	}
+}
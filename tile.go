@@ -0,0 +1,132 @@
+package healpix
+
+import "fmt"
+
+// One face (base pixel) of a HEALPix map laid out as a square raster, padded on every side by a halo of
+// cells copied from the neighboring faces. This lets stencil computations such as finite differences run
+// over plain array offsets instead of a neighbor lookup per pixel. The raster is row-major in FacePixel
+// x/y order: the cell of pixel (x, y) is at Cells()[(y+Halo())*Stride() + x+Halo()], and the halo cells
+// have x or y coordinates from -Halo() to -1 or from NSide to NSide+Halo()-1. Halo cells are filled across
+// face edges following the same rules as Neighbor, so cells across the rotated edges around the poles
+// line up with the interior. Where only three faces meet at a vertex of the face there is no fourth face
+// to fill the corner block of the halo, so those cells are left alone and HasSource reports false for them.
+type FaceTile[T any] struct {
+	hp      Healpix
+	face    int
+	halo    int
+	stride  int
+	cells   []T
+	sources []int // pixel id in the map for each cell, or -1 if the cell has none
+}
+
+// Create a tile of the given face of the HEALPix map, with a halo of the given width, for maps indexed in the
+// given scheme. The cells start with the zero value of T; use Fill to copy them from a map. The halo may be at
+// most one face wide. Panics if the face or halo width is invalid.
+func NewFaceTile[T any](hp Healpix, face int, halo int, scheme HealpixScheme) *FaceTile[T] {
+	nside := hp.FaceSidePixels()
+	if face < 0 || face >= 12 {
+		panic(fmt.Sprintf("healpix: invalid face %d for face tile", face))
+	}
+	if halo < 0 || halo > nside {
+		panic(fmt.Sprintf("healpix: face tile halo %d must be between 0 and the face side of %d pixels", halo, nside))
+	}
+	stride := nside + 2*halo
+	sources := make([]int, stride*stride)
+	for y := -halo; y < nside+halo; y++ {
+		for x := -halo; x < nside+halo; x++ {
+			source := -1
+			if fp, ok := facePixelAt(hp, face, x, y); ok {
+				source = int(fp.PixelId(hp, scheme))
+			}
+			sources[(y+halo)*stride+x+halo] = source
+		}
+	}
+	return &FaceTile[T]{hp, face, halo, stride, make([]T, stride*stride), sources}
+}
+
+// Create a tile of each of the twelve faces of the HEALPix map, all with the same halo width and scheme.
+func NewFaceTiles[T any](hp Healpix, halo int, scheme HealpixScheme) [12]*FaceTile[T] {
+	tiles := [12]*FaceTile[T]{}
+	for face := range tiles {
+		tiles[face] = NewFaceTile[T](hp, face, halo, scheme)
+	}
+	return tiles
+}
+
+// The HEALPix map the tile is a face of.
+func (t *FaceTile[T]) Healpix() Healpix {
+	return t.hp
+}
+
+// The index of the face the tile covers.
+func (t *FaceTile[T]) Face() int {
+	return t.face
+}
+
+// The width of the halo around the face, in pixels.
+func (t *FaceTile[T]) Halo() int {
+	return t.halo
+}
+
+// The number of cells in each row of the raster, which is NSide plus twice the halo width.
+func (t *FaceTile[T]) Stride() int {
+	return t.stride
+}
+
+// The cells of the raster, including the halo, in row-major order. Changes to the returned slice change
+// the tile.
+func (t *FaceTile[T]) Cells() []T {
+	return t.cells
+}
+
+// The position in Cells() of the cell at face coordinates x, y, which may lie in the halo.
+func (t *FaceTile[T]) Index(x int, y int) int {
+	return (y+t.halo)*t.stride + x + t.halo
+}
+
+// The value of the cell at face coordinates x, y, which may lie in the halo.
+func (t *FaceTile[T]) At(x int, y int) T {
+	return t.cells[t.Index(x, y)]
+}
+
+// Set the value of the cell at face coordinates x, y, which may lie in the halo.
+func (t *FaceTile[T]) Set(x int, y int, value T) {
+	t.cells[t.Index(x, y)] = value
+}
+
+// Whether the cell at face coordinates x, y corresponds to a pixel of the map. Only the halo cells beyond
+// a vertex where three faces meet have no pixel.
+func (t *FaceTile[T]) HasSource(x int, y int) bool {
+	return t.sources[t.Index(x, y)] >= 0
+}
+
+// Copy the interior and halo cells of the tile from the map, which is indexed in the scheme of the tile.
+// Panics if the map does not have one value per pixel.
+func (t *FaceTile[T]) Fill(m []T) {
+	t.checkMap(m)
+	for i, source := range t.sources {
+		if source >= 0 {
+			t.cells[i] = m[source]
+		}
+	}
+}
+
+// Copy the interior cells of the tile back into the map, which is indexed in the scheme of the tile. The
+// halo is not written, as its cells belong to the tiles of the neighboring faces. Panics if the map does not
+// have one value per pixel.
+func (t *FaceTile[T]) WriteBack(m []T) {
+	t.checkMap(m)
+	nside := t.hp.FaceSidePixels()
+	for y := 0; y < nside; y++ {
+		row := t.Index(0, y)
+		for i := row; i < row+nside; i++ {
+			m[t.sources[i]] = t.cells[i]
+		}
+	}
+}
+
+func (t *FaceTile[T]) checkMap(m []T) {
+	if uint(len(m)) != t.hp.Pixels() {
+		panic(fmt.Sprintf("healpix: map has %d values but the HEALPix map has %d pixels", len(m), t.hp.Pixels()))
+	}
+}
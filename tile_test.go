@@ -0,0 +1,139 @@
+package healpix
+
+import (
+	"testing"
+)
+
+func TestFaceTileHaloMatchesNeighbors(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	nside := hp.FaceSidePixels()
+	m := make([]uint, hp.Pixels())
+	for p := range m {
+		m[p] = uint(p)
+	}
+	for _, tile := range NewFaceTiles[uint](hp, 1, NestScheme) {
+		tile.Fill(m)
+		for y := 0; y < nside; y++ {
+			for x := 0; x < nside; x++ {
+				fp := NewFacePixel(tile.Face(), x, y)
+				if tile.At(x, y) != uint(fp.ToNestPixel(hp)) {
+					t.Fatalf("Face %d: interior cell %d,%d expected %v, got %v instead", tile.Face(), x, y, fp.ToNestPixel(hp), tile.At(x, y))
+				}
+				for d := South; d <= SouthWest; d++ {
+					xo, yo := d.Offsets()
+					neigh, ok := neighborFacePixel(hp, fp, d)
+					if ok != tile.HasSource(x+xo, y+yo) {
+						t.Fatalf("Face %d: cell %d,%d expected source %v", tile.Face(), x+xo, y+yo, ok)
+					}
+					if ok && tile.At(x+xo, y+yo) != uint(neigh.ToNestPixel(hp)) {
+						t.Fatalf("Face %d: cell %d,%d expected %v, got %v instead", tile.Face(), x+xo, y+yo, neigh.ToNestPixel(hp), tile.At(x+xo, y+yo))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFaceTileDeepHaloIsContinuous(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	nside := hp.FaceSidePixels()
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		m := make([]int, hp.Pixels())
+		for p := range m {
+			m[p] = p
+		}
+		for _, tile := range NewFaceTiles[int](hp, nside, scheme) {
+			tile.Fill(m)
+			missing := 0
+			for y := -nside; y < 2*nside; y++ {
+				for x := -nside; x < 2*nside; x++ {
+					if !tile.HasSource(x, y) {
+						missing++
+						continue
+					}
+					a := pixelOf(uint(tile.At(x, y)), scheme).ToFacePixel(hp)
+					// cells one step apart along x or y must hold pixels sharing an edge
+					for _, step := range [][2]int{{1, 0}, {0, 1}} {
+						nx, ny := x+step[0], y+step[1]
+						if nx >= 2*nside || ny >= 2*nside || !tile.HasSource(nx, ny) {
+							continue
+						}
+						b := pixelOf(uint(tile.At(nx, ny)), scheme).ToFacePixel(hp)
+						adjacent := false
+						for _, d := range []Direction{SouthEast, NorthEast, NorthWest, SouthWest} {
+							if n, ok := neighborFacePixel(hp, a, d); ok && n == b {
+								adjacent = true
+							}
+						}
+						if !adjacent {
+							t.Fatalf("Face %d: cells %d,%d and %d,%d hold pixels %v and %v which do not share an edge", tile.Face(), x, y, nx, ny, a, b)
+						}
+					}
+				}
+			}
+			// each vertex where three faces meet leaves one corner block of the halo empty
+			threeFaceVertices := 0
+			for _, d := range []Direction{South, East, North, West} {
+				xo, yo := d.Offsets()
+				if neighborFaces[4+xo+3*yo][tile.Face()] < 0 {
+					threeFaceVertices++
+				}
+			}
+			if missing != threeFaceVertices*nside*nside {
+				t.Errorf("Face %d: expected %d cells without a source, got %d instead", tile.Face(), threeFaceVertices*nside*nside, missing)
+			}
+		}
+	}
+}
+
+func pixelOf(id uint, scheme HealpixScheme) Where {
+	if scheme == RingScheme {
+		return RingPixel(id)
+	}
+	return NestPixel(id)
+}
+
+func TestFaceTileWriteBack(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	for _, scheme := range []HealpixScheme{NestScheme, RingScheme} {
+		m := make([]float64, hp.Pixels())
+		for p := range m {
+			m[p] = float64(p)
+		}
+		out := make([]float64, hp.Pixels())
+		for _, tile := range NewFaceTiles[float64](hp, 2, scheme) {
+			tile.Fill(m)
+			for i := range tile.Cells() {
+				tile.Cells()[i] *= 2
+			}
+			tile.WriteBack(out)
+		}
+		for p := range out {
+			if out[p] != 2*m[p] {
+				t.Fatalf("Pixel %d expected %v, got %v instead", p, 2*m[p], out[p])
+			}
+		}
+	}
+}
+
+func TestFaceTileInvalidArgumentsPanic(t *testing.T) {
+	hp := New(NewHealpixOrder(1))
+	testCases := []struct {
+		name string
+		fn   func()
+	}{
+		{"Invalid face", func() { NewFaceTile[int](hp, 12, 1, NestScheme) }},
+		{"Halo wider than a face", func() { NewFaceTile[int](hp, 0, 3, NestScheme) }},
+		{"Map of the wrong size", func() { NewFaceTile[int](hp, 0, 1, NestScheme).Fill(make([]int, 12)) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic")
+				}
+			}()
+			tc.fn()
+		})
+	}
+}
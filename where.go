@@ -3,11 +3,13 @@ package healpix
 import (
 	"fmt"
 	"math"
-	"math/bits"
 
 	"github.com/owlpinetech/flatsphere"
 )
 
+// The standard HEALPix projection is stateless, so a single shared value serves every conversion.
+var projection = flatsphere.NewHEALPixStandard()
+
 // An interface for converting between different indexing schemes and accessing the desired
 // pixel index given a HEALPix map with a specific indexing scheme, regardless of which scheme
 // the index itself references.
@@ -82,42 +84,73 @@ func (p NestPixel) PixelId(hp Healpix, scheme HealpixScheme) uint {
 }
 
 // The index of a pixel in a HEALPix map in nested numbering, combined with the order of the HEALPix map resolution.
-// Useful for indexing in multiresolution HEALPix maps.
+// Useful for indexing in multiresolution HEALPix maps. Follows the NUNIQ definition, 4*NSide^2 + nest, so the
+// order is encoded in the value itself. Because of this, every conversion from a UniquePixel describes the cell
+// at its own order, and the Healpix argument of each Where method is ignored. This allows cells of different
+// orders to be mixed freely; use Ancestor, FirstChild or LastChild to move a cell to another order.
 type UniquePixel uint
 
+// Create a unique pixel from the order of the map and the nest scheme index of the pixel at that order.
+// Panics if the order is invalid or the nest index is outside the map.
+func NewUniquePixel(order int, nest NestPixel) UniquePixel {
+	if !IsValidOrder(order) || uint(nest) >= New(HealpixOrder(order)).Pixels() {
+		panic("healpix: attempt to create UniquePixel with invalid order or nest argument")
+	}
+	return UniquePixel(4<<(2*order) + uint(nest))
+}
+
+// The HEALPix map at the order encoded in the pixel. Panics if the pixel is not a valid NUNIQ value.
+func (p UniquePixel) base() Healpix {
+	if !IsValidUniquePixel(uint(p)) {
+		panic("healpix: invalid unique pixel value")
+	}
+	return New(HealpixOrder(p.Order()))
+}
+
+// The nest scheme index of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToNestPixel(hp Healpix) NestPixel {
-	logged := bits.Len(uint(p)/4) - 1                      // integer version of floor(log2(p/4))
-	nside := uint(1) << int(math.Floor(float64(logged)/2)) // 2 ^ floor(log2(p/4)/2)
-	nest := uint(p) - 4*nside*nside
-	return NestPixel(nest)
+	p.base()
+	return p.NestIndex()
 }
 
 func (p UniquePixel) ToUniquePixel(hp Healpix) UniquePixel {
 	return p
 }
 
+// The ring scheme index of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToRingPixel(hp Healpix) RingPixel {
-	return p.ToNestPixel(hp).ToRingPixel(hp)
+	own := p.base()
+	return p.NestIndex().ToRingPixel(own)
 }
 
+// The face pixel of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToFacePixel(hp Healpix) FacePixel {
-	return p.ToNestPixel(hp).ToFacePixel(hp)
+	own := p.base()
+	return p.NestIndex().ToFacePixel(own)
 }
 
+// The ring coordinate of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToRingCoordinate(hp Healpix) RingCoordinate {
-	return p.ToNestPixel(hp).ToRingCoordinate(hp)
+	own := p.base()
+	return p.NestIndex().ToRingCoordinate(own)
 }
 
+// The projected center of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToProjectionCoordinate(hp Healpix) ProjectionCoordinate {
-	return p.ToNestPixel(hp).ToProjectionCoordinate(hp)
+	own := p.base()
+	return p.NestIndex().ToProjectionCoordinate(own)
 }
 
+// The center of the cell at its own order. The Healpix argument is ignored.
 func (p UniquePixel) ToSphereCoordinate(hp Healpix) SphereCoordinate {
-	return p.ToNestPixel(hp).ToSphereCoordinate(hp)
+	own := p.base()
+	return p.NestIndex().ToSphereCoordinate(own)
 }
 
+// The index of the cell at its own order in the given numbering scheme. The Healpix argument is ignored.
 func (p UniquePixel) PixelId(hp Healpix, scheme HealpixScheme) uint {
-	return p.ToNestPixel(hp).PixelId(hp, scheme)
+	own := p.base()
+	return p.NestIndex().PixelId(own, scheme)
 }
 
 // The index of a pixel in a HEALPix map counting ring-wise down from the north pole.
@@ -281,21 +314,10 @@ func (p RingCoordinate) ToProjectionCoordinate(hp Healpix) ProjectionCoordinate
 func (p RingCoordinate) ToSphereCoordinate(hp Healpix) SphereCoordinate {
 	// ring abstraction does the heavy liftng for latitude
 	ring := NewRing(hp, p.ring)
-	longitude := float64(0)
-	// longitude is the same on both north and south hemispheres, so simplify by only computing for north
-	if ring.northIndex < hp.FaceSidePixels() {
-		longitude = (math.Pi / (2 * float64(ring.northIndex+1))) * (float64(p.pixelInRing) + float64(1)/float64(2))
-	} else {
-		shift := 0.0
-		if ring.IsOffset() {
-			shift = 1.0
-		}
-		longitude = (math.Pi / (2 * float64(hp.FaceSidePixels()))) * (float64(p.pixelInRing) + shift/2.0)
-	}
 	return SphereCoordinate{
 		ring.Latitude(),
 		ring.Colatitude(),
-		longitude,
+		ring.LongitudeOf(p.pixelInRing),
 	}
 }
 
@@ -380,9 +402,13 @@ func (p FacePixel) ToRingCoordinate(hp Healpix) RingCoordinate {
 		s = 0
 	}
 
-	pixelInRing := (southX*(ring.Pixels()>>2) + h + s) / 2
+	// the numerator is always even, so the division is exact even when it is negative and
+	// the pixel wraps around to the end of the ring west of the meridian
+	pixelInRing := (southX*(ring.Pixels()>>2)+h+1+s)/2 - 1
 	if pixelInRing < 0 {
-		pixelInRing += ring.Pixels() - 1
+		pixelInRing += ring.Pixels()
+	} else if pixelInRing >= ring.Pixels() {
+		pixelInRing -= ring.Pixels()
 	}
 	return RingCoordinate{ringId, pixelInRing}
 }
@@ -411,6 +437,30 @@ func (p FacePixel) ToSphereCoordinate(hp Healpix) SphereCoordinate {
 	return p.ToRingCoordinate(hp).ToSphereCoordinate(hp)
 }
 
+// The four corners of the pixel on the sphere, in the order north, west, south, east.
+func (p FacePixel) Vertices(hp Healpix) [4]SphereCoordinate {
+	vertices := [4]SphereCoordinate{}
+	for i, v := range p.vertices(hp) {
+		vertices[i] = v.ToSphereCoordinate(hp)
+	}
+	return vertices
+}
+
+// The four corners of the pixel within its face, in the order north, west, south, east.
+func (p FacePixel) vertices(hp Healpix) [4]FaceCoordinate {
+	nside := float64(hp.FaceSidePixels())
+	x0 := float64(p.x) / nside
+	y0 := float64(p.y) / nside
+	x1 := float64(p.x+1) / nside
+	y1 := float64(p.y+1) / nside
+	return [4]FaceCoordinate{
+		{p.face, x1, y1},
+		{p.face, x0, y1},
+		{p.face, x0, y0},
+		{p.face, x1, y0},
+	}
+}
+
 func (p FacePixel) PixelId(hp Healpix, scheme HealpixScheme) uint {
 	if scheme == RingScheme {
 		return uint(p.ToRingPixel(hp))
@@ -418,6 +468,125 @@ func (p FacePixel) PixelId(hp Healpix, scheme HealpixScheme) uint {
 	return uint(p.ToNestPixel(hp))
 }
 
+// Describes a continuous position within a face (base pixel), in the same x/y orientation as FacePixel but in
+// units of the face side rather than of pixels. So 0,0 is the southernmost vertex of the face and 1,1 is the
+// northernmost vertex, independent of the resolution of any HEALPix map. Because the fractional position inside
+// each pixel is kept, a single FaceCoordinate can be converted to the containing pixel at every order without
+// repeating any trigonometry.
+type FaceCoordinate struct {
+	face int
+	x    float64
+	y    float64
+}
+
+// Create a new face coordinate from the given face and x/y position in [0,1] face units.
+func NewFaceCoordinate(face int, x float64, y float64) FaceCoordinate {
+	return FaceCoordinate{face, x, y}
+}
+
+// Returns the position along the north-east direction of the face, from 0 at the southernmost vertex to 1.
+func (p FaceCoordinate) X() float64 {
+	return p.x
+}
+
+// Returns the position along the north-west direction of the face, from 0 at the southernmost vertex to 1.
+func (p FaceCoordinate) Y() float64 {
+	return p.y
+}
+
+// Returns the index identifier of the face in which the position is found.
+func (p FaceCoordinate) Face() int {
+	return p.face
+}
+
+func (p FaceCoordinate) ToNestPixel(hp Healpix) NestPixel {
+	return p.ToFacePixel(hp).ToNestPixel(hp)
+}
+
+func (p FaceCoordinate) ToUniquePixel(hp Healpix) UniquePixel {
+	return p.ToFacePixel(hp).ToNestPixel(hp).ToUniquePixel(hp)
+}
+
+func (p FaceCoordinate) ToRingPixel(hp Healpix) RingPixel {
+	return p.ToFacePixel(hp).ToRingCoordinate(hp).ToRingPixel(hp)
+}
+
+// Find the pixel containing the position by scaling it to the resolution of the map. The pixel indices are
+// floored, so a position exactly on a pixel boundary belongs to the pixel with the larger x or y, except on
+// the northern edges of the face (x or y == 1), which belong to the last pixel. This only differs from the
+// boundary rule of SphereCoordinate.ToFacePixel for positions lying exactly on a boundary.
+func (p FaceCoordinate) ToFacePixel(hp Healpix) FacePixel {
+	nside := hp.FaceSidePixels()
+	x := min(int(p.x*float64(nside)), nside-1)
+	y := min(int(p.y*float64(nside)), nside-1)
+	return FacePixel{x, y, p.face}
+}
+
+func (p FaceCoordinate) ToRingCoordinate(hp Healpix) RingCoordinate {
+	return p.ToFacePixel(hp).ToRingCoordinate(hp)
+}
+
+func (p FaceCoordinate) ToProjectionCoordinate(hp Healpix) ProjectionCoordinate {
+	return p.ToSphereCoordinate(hp).ToProjectionCoordinate(hp)
+}
+
+// The exact position on the sphere of the face coordinate, which does not depend on the HEALPix resolution.
+func (p FaceCoordinate) ToSphereCoordinate(hp Healpix) SphereCoordinate {
+	z, sth, lon := p.sphere()
+	return SphereCoordinate{math.Atan2(z, sth), math.Atan2(sth, z), lon}
+}
+
+// The exact unit vector of the face coordinate, which does not depend on the HEALPix resolution.
+func (p FaceCoordinate) ToVector() Vector {
+	z, sth, lon := p.sphere()
+	return Vector{sth * math.Cos(lon), sth * math.Sin(lon), z}
+}
+
+func (p FaceCoordinate) PixelId(hp Healpix, scheme HealpixScheme) uint {
+	if scheme == RingScheme {
+		return uint(p.ToRingPixel(hp))
+	}
+	return uint(p.ToNestPixel(hp))
+}
+
+// The inverse of the continuous ang2pix mapping of Gorski et al. (2005). Returns z = cos(colatitude),
+// s = sin(colatitude) and the longitude in [0, 2Pi). s is computed directly near the poles rather than
+// from z, to keep full precision there.
+func (p FaceCoordinate) sphere() (float64, float64, float64) {
+	southX, southY := NewFace(p.face).SouthernmostVertex()
+	// ring coordinate in units of face sides, from 0 at the north pole to 4 at the south pole
+	jr := float64(southY) - p.x - p.y
+
+	var nr, z, sth float64
+	if jr < 1 {
+		nr = jr
+		tmp := nr * nr / 3
+		z = 1 - tmp
+		sth = math.Sqrt(tmp * (2 - tmp))
+	} else if jr > 3 {
+		nr = 4 - jr
+		tmp := nr * nr / 3
+		z = tmp - 1
+		sth = math.Sqrt(tmp * (2 - tmp))
+	} else {
+		nr = 1
+		z = (2 - jr) * 2 / 3
+		sth = math.Sqrt((1 - z) * (1 + z))
+	}
+
+	tmp := float64(southX)*nr + p.x - p.y
+	if tmp < 0 {
+		tmp += 8
+	} else if tmp >= 8 {
+		tmp -= 8
+	}
+	lon := 0.0
+	if nr > 1e-15 {
+		lon = (math.Pi / 4) * tmp / nr
+	}
+	return z, sth, lon
+}
+
 // Represents a position on a HEALPix sphere projected into the standard HEALPix projection on a 2D plane.
 type ProjectionCoordinate struct {
 	x float64
@@ -488,7 +657,7 @@ func (p ProjectionCoordinate) ToProjectionCoordinate(hp Healpix) ProjectionCoord
 }
 
 func (p ProjectionCoordinate) ToSphereCoordinate(hp Healpix) SphereCoordinate {
-	lat, lon := flatsphere.NewHEALPixStandard().Inverse(p.x, p.y)
+	lat, lon := projection.Inverse(p.x, p.y)
 	return NewLatLonCoordinate(lat, lon)
 }
 
@@ -510,12 +679,12 @@ type SphereCoordinate struct {
 
 // Create a SphereCoordinate structure from a latitude/longitude pair.
 func NewLatLonCoordinate(lat float64, lon float64) SphereCoordinate {
-	return SphereCoordinate{lat, lat + math.Pi/2, lon}
+	return SphereCoordinate{lat, math.Pi/2 - lat, lon}
 }
 
 // Create a SphereCoordinate structure from a colatitude/longitude pair.
 func NewColatLonCoordinate(colat float64, lon float64) SphereCoordinate {
-	return SphereCoordinate{colat - math.Pi/2, colat, lon}
+	return SphereCoordinate{math.Pi/2 - colat, colat, lon}
 }
 
 // The latitude component of the coordinate on the sphere, in units of radians.
@@ -534,27 +703,132 @@ func (p SphereCoordinate) Longitude() float64 {
 }
 
 func (p SphereCoordinate) ToNestPixel(hp Healpix) NestPixel {
-	return p.ToProjectionCoordinate(hp).ToFacePixel(hp).ToNestPixel(hp)
+	return p.ToFacePixel(hp).ToNestPixel(hp)
 }
 
 func (p SphereCoordinate) ToUniquePixel(hp Healpix) UniquePixel {
-	return p.ToProjectionCoordinate(hp).ToFacePixel(hp).ToNestPixel(hp).ToUniquePixel(hp)
+	return p.ToFacePixel(hp).ToNestPixel(hp).ToUniquePixel(hp)
 }
 
 func (p SphereCoordinate) ToRingPixel(hp Healpix) RingPixel {
-	return p.ToProjectionCoordinate(hp).ToFacePixel(hp).ToRingCoordinate(hp).ToRingPixel(hp)
+	return p.ToFacePixel(hp).ToRingCoordinate(hp).ToRingPixel(hp)
 }
 
+// Find the pixel containing the position directly on the sphere, following the ang2pix algorithm of
+// Gorski et al. (2005), without an intermediate projection. Near the poles the distance from the pole is
+// computed from sin(colatitude) rather than 1 - sin(latitude), which keeps full precision there.
+//
+// A position lying exactly on a pixel boundary is assigned to a single pixel by flooring both of the
+// diagonal boundary coordinates of the position, the same rule used by the reference HEALPix library and
+// healpy. In practice this places a boundary position in the pixel to its east; a position exactly on a
+// face vertex is placed in the face that contains the pixel to its east. Longitudes are reduced to [0, 2Pi)
+// first, so a position on the 2Pi meridian is treated exactly like one on the 0 meridian.
 func (p SphereCoordinate) ToFacePixel(hp Healpix) FacePixel {
-	return p.ToProjectionCoordinate(hp).ToFacePixel(hp)
+	z := math.Sin(p.latitude)
+	sth := math.Sin(p.colatitude)
+	return sphereToFacePixel(hp, z, sth, p.longitude)
+}
+
+// The native ang2pix core, shared by every position type that can provide z = cos(colatitude),
+// s = sin(colatitude) and the longitude.
+func sphereToFacePixel(hp Healpix, z float64, sth float64, lon float64) FacePixel {
+	nside := hp.FaceSidePixels()
+	za := math.Abs(z)
+	// longitude in units of quarter turns, in [0, 4)
+	tt := quarterTurns(lon)
+
+	if za <= 2.0/3.0 {
+		// equatorial region: pixel boundaries are straight lines in (tt, z)
+		temp1 := float64(nside) * (0.5 + tt)
+		temp2 := float64(nside) * (z * 0.75)
+		jp := int(temp1 - temp2) // index of the ascending edge line
+		jm := int(temp1 + temp2) // index of the descending edge line
+		ifp := jp >> hp.Order()
+		ifm := jm >> hp.Order()
+		return FacePixel{jm & (nside - 1), nside - (jp & (nside - 1)) - 1, equatorialFace(ifp, ifm)}
+	}
+
+	// polar caps
+	ntt := min(3, int(tt))
+	tp := tt - float64(ntt)
+	tmp := float64(nside) * polarDistance(za, sth)
+	jp := min(int(tp*tmp), nside-1)
+	jm := min(int((1-tp)*tmp), nside-1)
+	if z >= 0 {
+		return FacePixel{nside - jm - 1, nside - jp - 1, ntt}
+	}
+	return FacePixel{jp, jm, ntt + 8}
+}
+
+// The continuous version of sphereToFacePixel, giving the position within the face rather than the pixel.
+func sphereToFaceCoordinate(z float64, sth float64, lon float64) FaceCoordinate {
+	za := math.Abs(z)
+	tt := quarterTurns(lon)
+
+	if za <= 2.0/3.0 {
+		jp := 0.5 + tt - z*0.75
+		jm := 0.5 + tt + z*0.75
+		ifp := int(jp)
+		ifm := int(jm)
+		return FaceCoordinate{equatorialFace(ifp, ifm), jm - float64(ifm), float64(ifp) + 1 - jp}
+	}
+
+	ntt := min(3, int(tt))
+	tp := tt - float64(ntt)
+	tmp := polarDistance(za, sth)
+	jp := min(tp*tmp, 1)
+	jm := min((1-tp)*tmp, 1)
+	if z >= 0 {
+		return FaceCoordinate{ntt, 1 - jm, 1 - jp}
+	}
+	return FaceCoordinate{ntt + 8, jp, jm}
+}
+
+// Reduce a longitude to units of quarter turns, in [0, 4).
+func quarterTurns(lon float64) float64 {
+	tt := math.Mod(lon*(2/math.Pi), 4)
+	if tt < 0 {
+		tt += 4
+	}
+	return tt
+}
+
+// The face of a position in the equatorial region, from the face-sized indices of its two diagonal edge lines.
+func equatorialFace(ifp int, ifm int) int {
+	if ifp == ifm {
+		return (ifp & 3) | 4
+	} else if ifp < ifm {
+		return ifp & 3
+	}
+	return (ifm & 3) + 8
+}
+
+// The distance of a polar cap position from its pole, in units of face sides, given |z| and sin(colatitude).
+func polarDistance(za float64, sth float64) float64 {
+	if za < 0.99 {
+		return math.Sqrt(3 * (1 - za))
+	}
+	// 1 - za loses precision close to the pole, but sin(colatitude) does not
+	return sth / math.Sqrt((1+za)/3)
+}
+
+// The continuous position of the coordinate within its face, independent of any HEALPix resolution.
+func (p SphereCoordinate) ToFaceCoordinate() FaceCoordinate {
+	return sphereToFaceCoordinate(math.Sin(p.latitude), math.Sin(p.colatitude), p.longitude)
+}
+
+// The unit vector pointing at the coordinate on the sphere.
+func (p SphereCoordinate) ToVector() Vector {
+	sth := math.Sin(p.colatitude)
+	return Vector{sth * math.Cos(p.longitude), sth * math.Sin(p.longitude), math.Sin(p.latitude)}
 }
 
 func (p SphereCoordinate) ToRingCoordinate(hp Healpix) RingCoordinate {
-	return p.ToProjectionCoordinate(hp).ToFacePixel(hp).ToRingCoordinate(hp)
+	return p.ToFacePixel(hp).ToRingCoordinate(hp)
 }
 
 func (p SphereCoordinate) ToProjectionCoordinate(hp Healpix) ProjectionCoordinate {
-	x, y := flatsphere.NewHEALPixStandard().Project(p.Latitude(), p.Longitude())
+	x, y := projection.Project(p.Latitude(), p.Longitude())
 	return NewProjectionCoordinate(x, y)
 }
 
@@ -568,3 +842,301 @@ func (p SphereCoordinate) PixelId(hp Healpix, scheme HealpixScheme) uint {
 	}
 	return uint(p.ToNestPixel(hp))
 }
+
+// A position on the sphere represented by a cartesian 3-vector from the center of the sphere, with z pointing
+// at the north pole and x pointing at 0 longitude. The vector need not be normalized, but must not be zero.
+type Vector struct {
+	x float64
+	y float64
+	z float64
+}
+
+// Create a Vector from its cartesian components.
+func NewVector(x float64, y float64, z float64) Vector {
+	return Vector{x, y, z}
+}
+
+// The component of the vector toward 0 longitude on the equator.
+func (v Vector) X() float64 {
+	return v.x
+}
+
+// The component of the vector toward Pi/2 longitude on the equator.
+func (v Vector) Y() float64 {
+	return v.y
+}
+
+// The component of the vector toward the north pole.
+func (v Vector) Z() float64 {
+	return v.z
+}
+
+// The euclidean length of the vector.
+func (v Vector) Length() float64 {
+	return math.Sqrt(v.x*v.x + v.y*v.y + v.z*v.z)
+}
+
+// The vector scaled to unit length.
+func (v Vector) Normalize() Vector {
+	l := v.Length()
+	return Vector{v.x / l, v.y / l, v.z / l}
+}
+
+func (v Vector) ToNestPixel(hp Healpix) NestPixel {
+	return v.ToFacePixel(hp).ToNestPixel(hp)
+}
+
+func (v Vector) ToUniquePixel(hp Healpix) UniquePixel {
+	return v.ToFacePixel(hp).ToNestPixel(hp).ToUniquePixel(hp)
+}
+
+func (v Vector) ToRingPixel(hp Healpix) RingPixel {
+	return v.ToFacePixel(hp).ToRingCoordinate(hp).ToRingPixel(hp)
+}
+
+// Find the pixel containing the direction of the vector, with the same algorithm and boundary rule as
+// SphereCoordinate.ToFacePixel.
+func (v Vector) ToFacePixel(hp Healpix) FacePixel {
+	l := v.Length()
+	return sphereToFacePixel(hp, v.z/l, math.Hypot(v.x, v.y)/l, math.Atan2(v.y, v.x))
+}
+
+func (v Vector) ToRingCoordinate(hp Healpix) RingCoordinate {
+	return v.ToFacePixel(hp).ToRingCoordinate(hp)
+}
+
+func (v Vector) ToProjectionCoordinate(hp Healpix) ProjectionCoordinate {
+	return v.ToSphereCoordinate(hp).ToProjectionCoordinate(hp)
+}
+
+func (v Vector) ToSphereCoordinate(hp Healpix) SphereCoordinate {
+	return v.sphere()
+}
+
+// The position of the vector on the sphere, which does not depend on any HEALPix resolution.
+func (v Vector) sphere() SphereCoordinate {
+	rho := math.Hypot(v.x, v.y)
+	lon := math.Atan2(v.y, v.x)
+	if lon < 0 {
+		lon += 2 * math.Pi
+	}
+	return SphereCoordinate{math.Atan2(v.z, rho), math.Atan2(rho, v.z), lon}
+}
+
+// The continuous position of the direction of the vector within its face, independent of any HEALPix resolution.
+func (v Vector) ToFaceCoordinate() FaceCoordinate {
+	l := v.Length()
+	return sphereToFaceCoordinate(v.z/l, math.Hypot(v.x, v.y)/l, math.Atan2(v.y, v.x))
+}
+
+func (v Vector) PixelId(hp Healpix, scheme HealpixScheme) uint {
+	if scheme == RingScheme {
+		return uint(v.ToRingPixel(hp))
+	}
+	return uint(v.ToNestPixel(hp))
+}
+
+func (v Vector) dot(w Vector) float64 {
+	return v.x*w.x + v.y*w.y + v.z*w.z
+}
+
+func (v Vector) cross(w Vector) Vector {
+	return Vector{v.y*w.z - v.z*w.y, v.z*w.x - v.x*w.z, v.x*w.y - v.y*w.x}
+}
+
+func (v Vector) add(w Vector) Vector {
+	return Vector{v.x + w.x, v.y + w.y, v.z + w.z}
+}
+
+func (v Vector) scale(s float64) Vector {
+	return Vector{v.x * s, v.y * s, v.z * s}
+}
+
+// The unit vector of any position. Positions that are already vectors or continuous face coordinates
+// are converted directly, without a round trip through latitude and longitude.
+func toVector(hp Healpix, where Where) Vector {
+	switch w := where.(type) {
+	case Vector:
+		return w.Normalize()
+	case FaceCoordinate:
+		return w.ToVector()
+	default:
+		return where.ToSphereCoordinate(hp).ToVector()
+	}
+}
+
+// A directed great circle arc between two positions on the unit sphere, parameterized by the angle
+// travelled from the start.
+type greatArc struct {
+	u      Vector  // unit vector of the start of the arc
+	v      Vector  // unit vector perpendicular to u, in the plane of the arc and pointing along it
+	length float64 // angular length of the arc in radians
+}
+
+// Create the shortest great circle arc from a to b. Panics if a and b are antipodal, as there is no
+// unique great circle between them.
+func newGreatArc(a Vector, b Vector) greatArc {
+	a = a.Normalize()
+	b = b.Normalize()
+	n := a.cross(b)
+	sin := n.Length()
+	cos := a.dot(b)
+	if sin == 0 {
+		if cos < 0 {
+			panic("healpix: the great circle between antipodal points is undefined")
+		}
+		// zero length arc, any perpendicular direction will do
+		return greatArc{a, Vector{}, 0}
+	}
+	v := n.cross(a).scale(1 / sin)
+	return greatArc{a, v, math.Atan2(sin, cos)}
+}
+
+// The position the given angle along the arc.
+func (g greatArc) at(theta float64) Vector {
+	return g.u.scale(math.Cos(theta)).add(g.v.scale(math.Sin(theta)))
+}
+
+// The angular distance from the unit vector p to the nearest point of the arc.
+func (g greatArc) distance(p Vector) float64 {
+	// angle of the projection of p onto the plane of the arc
+	theta := math.Atan2(p.dot(g.v), p.dot(g.u))
+	if theta >= 0 && theta <= g.length {
+		n := g.u.cross(g.v)
+		return math.Abs(math.Asin(max(-1, min(1, p.dot(n)))))
+	}
+	start := angleBetween(p, g.u)
+	end := angleBetween(p, g.at(g.length))
+	return min(start, end)
+}
+
+// The angle between two unit vectors, accurate for both small and large angles.
+func angleBetween(a Vector, b Vector) float64 {
+	return math.Atan2(a.cross(b).Length(), a.dot(b))
+}
+
+// The angular distance in radians between any two positions, using the pixel centers for positions
+// given as pixel indices. Computed from the vector cross and dot products, so it stays accurate for
+// positions that are nearly coincident or nearly antipodal, where the haversine and cosine forms lose
+// precision.
+func AngularDistance(hp Healpix, a Where, b Where) float64 {
+	return angleBetween(toVector(hp, a), toVector(hp, b))
+}
+
+// The angular distance in radians from this coordinate to another.
+func (p SphereCoordinate) AngularDistance(other SphereCoordinate) float64 {
+	return angleBetween(p.ToVector(), other.ToVector())
+}
+
+// The direction in which the shortest great circle path to the other coordinate leaves this one, in
+// radians clockwise from north in the range 0 - 2Pi. At the poles north is taken to be the direction
+// of the meridian at the longitude of the coordinate.
+func (p SphereCoordinate) InitialBearing(other SphereCoordinate) float64 {
+	return initialBearing(p.ToVector(), other.ToVector(), p.longitude)
+}
+
+// The coordinate reached by travelling the given angular distance along a great circle leaving this
+// coordinate in the direction of bearing, measured in radians clockwise from north.
+func (p SphereCoordinate) Destination(bearing float64, distance float64) SphereCoordinate {
+	return destination(p.ToVector(), bearing, distance, p.longitude).sphere()
+}
+
+// The coordinate halfway along the shortest great circle path to the other coordinate. Panics if the
+// coordinates are antipodal, as there is no unique path between them.
+func (p SphereCoordinate) Midpoint(other SphereCoordinate) SphereCoordinate {
+	return p.ToVector().Midpoint(other.ToVector()).sphere()
+}
+
+// The coordinate the given fraction of the way along the shortest great circle path to the other
+// coordinate, so that 0 is this coordinate and 1 is the other. Fractions outside 0 - 1 continue along
+// the same great circle. Panics if the coordinates are antipodal.
+func (p SphereCoordinate) Interpolate(other SphereCoordinate, fraction float64) SphereCoordinate {
+	return p.ToVector().Interpolate(other.ToVector(), fraction).sphere()
+}
+
+// The signed angular distance from this coordinate to the great circle through start and end, positive
+// when the coordinate lies to the right of the direction of travel from start to end. Panics if start
+// and end are coincident or antipodal, as they do not define a unique great circle.
+func (p SphereCoordinate) CrossTrackDistance(start SphereCoordinate, end SphereCoordinate) float64 {
+	return p.ToVector().CrossTrackDistance(start.ToVector(), end.ToVector())
+}
+
+// The angular distance in radians between the directions of two vectors.
+func (v Vector) AngularDistance(w Vector) float64 {
+	return angleBetween(v, w)
+}
+
+// The direction in which the shortest great circle path to the other vector leaves this one, in
+// radians clockwise from north in the range 0 - 2Pi. At the poles north is taken to be the direction
+// of the meridian at longitude 0.
+func (v Vector) InitialBearing(w Vector) float64 {
+	return initialBearing(v.Normalize(), w.Normalize(), 0)
+}
+
+// The unit vector reached by travelling the given angular distance along a great circle leaving this
+// vector in the direction of bearing, measured in radians clockwise from north.
+func (v Vector) Destination(bearing float64, distance float64) Vector {
+	return destination(v.Normalize(), bearing, distance, 0)
+}
+
+// The unit vector halfway along the shortest great circle path to the other vector. Panics if the
+// vectors are antipodal, as there is no unique path between them.
+func (v Vector) Midpoint(w Vector) Vector {
+	mid := v.Normalize().add(w.Normalize())
+	if mid.Length() == 0 {
+		panic("healpix: the midpoint of antipodal points is undefined")
+	}
+	return mid.Normalize()
+}
+
+// The unit vector the given fraction of the way along the shortest great circle path to the other
+// vector, so that 0 is this vector and 1 is the other. Fractions outside 0 - 1 continue along the same
+// great circle. Panics if the vectors are antipodal.
+func (v Vector) Interpolate(w Vector, fraction float64) Vector {
+	arc := newGreatArc(v, w)
+	return arc.at(fraction * arc.length)
+}
+
+// The signed angular distance from the direction of this vector to the great circle through start and
+// end, positive when the vector lies to the right of the direction of travel from start to end. Panics
+// if start and end are coincident or antipodal, as they do not define a unique great circle.
+func (v Vector) CrossTrackDistance(start Vector, end Vector) float64 {
+	n := start.Normalize().cross(end.Normalize())
+	if n.Length() == 0 {
+		panic("healpix: the great circle through coincident or antipodal points is undefined")
+	}
+	n = n.Normalize()
+	p := v.Normalize()
+	// the normal points to the left of the direction of travel
+	along := p.add(n.scale(-p.dot(n)))
+	return -math.Atan2(p.dot(n), along.Length())
+}
+
+// The unit vectors pointing east and north along the sphere at the unit vector p. At the poles, where
+// both are undefined, they are taken from the meridian at the given longitude.
+func localFrame(p Vector, lon float64) (Vector, Vector) {
+	east := Vector{-p.y, p.x, 0}
+	if l := east.Length(); l > 0 {
+		east = east.scale(1 / l)
+	} else {
+		east = Vector{-math.Sin(lon), math.Cos(lon), 0}
+	}
+	return east, p.cross(east)
+}
+
+func initialBearing(a Vector, b Vector, lon float64) float64 {
+	east, north := localFrame(a, lon)
+	// direction of travel at a, perpendicular to a in the plane of the great circle
+	travel := a.cross(b).cross(a)
+	bearing := math.Atan2(travel.dot(east), travel.dot(north))
+	if bearing < 0 {
+		bearing += 2 * math.Pi
+	}
+	return bearing
+}
+
+func destination(p Vector, bearing float64, distance float64, lon float64) Vector {
+	east, north := localFrame(p, lon)
+	travel := north.scale(math.Cos(bearing)).add(east.scale(math.Sin(bearing)))
+	return p.scale(math.Cos(distance)).add(travel.scale(math.Sin(distance))).Normalize()
+}
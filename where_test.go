@@ -2,6 +2,7 @@ package healpix
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 	"testing/quick"
 )
@@ -109,6 +110,44 @@ func TestFacePixelRingCoord(t *testing.T) {
 	}
 }
 
+// Pixels of face 4 just west of the meridian wrap around to the end of their ring. Their ring index used to
+// come out as 0, colliding with the first pixel east of the meridian.
+func TestFacePixelRingCoordWestOfMeridian(t *testing.T) {
+	testCases := []struct {
+		name  string
+		order HealpixOrder
+		nest  NestPixel
+		pixel FacePixel
+		coord RingCoordinate
+		ring  RingPixel
+	}{
+		{"1 order: nest 18", 1, 18, FacePixel{0, 1, 4}, RingCoordinate{3, 7}, 27},
+		{"2 order: nest 66", 2, 66, FacePixel{0, 1, 4}, RingCoordinate{9, 15}, 135},
+		{"2 order: nest 72", 2, 72, FacePixel{0, 2, 4}, RingCoordinate{8, 15}, 119},
+		{"2 order: nest 73", 2, 73, FacePixel{1, 2, 4}, RingCoordinate{7, 15}, 103},
+		{"2 order: nest 75", 2, 75, FacePixel{1, 3, 4}, RingCoordinate{6, 15}, 87},
+		{"2 order: nest 78", 2, 78, FacePixel{2, 3, 4}, RingCoordinate{5, 15}, 71},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hp := New(tc.order)
+			if face := tc.nest.ToFacePixel(hp); face != tc.pixel {
+				t.Errorf("Nest to face expected %v, got %v instead", tc.pixel, face)
+			}
+			if coord := tc.pixel.ToRingCoordinate(hp); coord != tc.coord {
+				t.Errorf("Face to coordinate expected %v, got %v instead", tc.coord, coord)
+			}
+			if ring := tc.nest.ToRingPixel(hp); ring != tc.ring {
+				t.Errorf("Nest to ring expected %v, got %v instead", tc.ring, ring)
+			}
+			if nest := tc.ring.ToNestPixel(hp); nest != tc.nest {
+				t.Errorf("Ring to nest expected %v, got %v instead", tc.nest, nest)
+			}
+		})
+	}
+}
+
 func TestNestPixelFacePixel(t *testing.T) {
 	testCases := []struct {
 		name  string
@@ -196,6 +235,74 @@ func TestNestPixelUniquePixel(t *testing.T) {
 	}
 }
 
+func TestUniquePixelUsesOwnOrder(t *testing.T) {
+	testCases := []struct {
+		name  string
+		order int
+		nest  NestPixel
+	}{
+		{"order 0, nest 5", 0, 5},
+		{"order 1, nest 17", 1, 17},
+		{"order 3, nest 700", 3, 700},
+		{"order 8, nest 12345", 8, 12345},
+	}
+
+	// deliberately a different order than any of the cells
+	other := New(NewHealpixOrder(5))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			own := New(NewHealpixOrder(tc.order))
+			uniq := NewUniquePixel(tc.order, tc.nest)
+			if uniq != tc.nest.ToUniquePixel(own) {
+				t.Errorf("NewUniquePixel expected %v, got %v instead", tc.nest.ToUniquePixel(own), uniq)
+			}
+			if r := uniq.ToNestPixel(other); r != tc.nest {
+				t.Errorf("Unique to nest expected %v, got %v instead", tc.nest, r)
+			}
+			if r := uniq.ToRingPixel(other); r != tc.nest.ToRingPixel(own) {
+				t.Errorf("Unique to ring expected %v, got %v instead", tc.nest.ToRingPixel(own), r)
+			}
+			if r := uniq.ToFacePixel(other); r != tc.nest.ToFacePixel(own) {
+				t.Errorf("Unique to face expected %v, got %v instead", tc.nest.ToFacePixel(own), r)
+			}
+			if r := uniq.ToSphereCoordinate(other); r != tc.nest.ToSphereCoordinate(own) {
+				t.Errorf("Unique to position expected %v, got %v instead", tc.nest.ToSphereCoordinate(own), r)
+			}
+			if r := uniq.PixelId(other, RingScheme); r != uint(tc.nest.ToRingPixel(own)) {
+				t.Errorf("Unique to ring id expected %v, got %v instead", tc.nest.ToRingPixel(own), r)
+			}
+		})
+	}
+}
+
+func TestInvalidUniquePixelPanics(t *testing.T) {
+	for _, uniq := range []UniquePixel{0, 1, 2, 3} {
+		if IsValidUniquePixel(uint(uniq)) {
+			t.Errorf("Unique pixel %v expected to be invalid", uniq)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected conversion of invalid unique pixel %v to panic", uniq)
+				}
+			}()
+			uniq.ToSphereCoordinate(New(NewHealpixOrder(0)))
+		}()
+	}
+	if !IsValidUniquePixel(4) {
+		t.Errorf("Unique pixel 4 expected to be valid")
+	}
+}
+
+func TestNewUniquePixelInvalidNestPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected nest index outside the map to panic")
+		}
+	}()
+	NewUniquePixel(0, 12)
+}
+
 func TestPositionToNestPixel(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -420,3 +527,275 @@ func TestNestRingSpherePositionsSame(t *testing.T) {
 		t.Errorf("Nest pixel and equivalent ring pixel do not have the same sphere position: %v", err)
 	}
 }
+
+func TestSphereToNestPixelCenters(t *testing.T) {
+	for order := 0; order <= 7; order++ {
+		hp := New(NewHealpixOrder(order))
+		for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+			if r := nest.ToSphereCoordinate(hp).ToNestPixel(hp); r != nest {
+				t.Fatalf("Order %d: center of nest pixel %v expected to map back to itself, got %v instead", order, nest, r)
+			}
+		}
+	}
+}
+
+func TestSphereToNestPixelMatchesProjection(t *testing.T) {
+	hp := New(NewHealpixOrder(10))
+	inside := func(nest NestPixel) bool {
+		if nest >= NestPixel(hp.Pixels()) {
+			return true
+		}
+		// nudge a quarter pixel off the center so both paths are well away from any boundary
+		center := nest.ToSphereCoordinate(hp)
+		pos := NewColatLonCoordinate(center.Colatitude(), center.Longitude()+hp.AngularResolution()/8)
+		return pos.ToNestPixel(hp) == pos.ToProjectionCoordinate(hp).ToFacePixel(hp).ToNestPixel(hp)
+	}
+	if err := quick.Check(inside, nil); err != nil {
+		t.Errorf("Native and projected angle to pixel conversions disagree: %v", err)
+	}
+}
+
+func TestSphereToNestPixelBoundaries(t *testing.T) {
+	testCases := []struct {
+		name       string
+		order      HealpixOrder
+		colatitude float64
+		longitude  float64
+		nest       NestPixel
+	}{
+		{"0 order: equator vertex between faces 4 and 5 goes east", 0, math.Pi / 2, math.Pi / 4, 5},
+		{"0 order: north vertex of face 4 goes to face 0", 0, math.Acos(2.0 / 3.0), 0, 0},
+		{"0 order: longitude 2Pi is longitude 0", 0, math.Acos(2.0 / 3.0), 2 * math.Pi, 0},
+		{"0 order: negative longitude wraps", 0, math.Pi / 2, -math.Pi / 8, 4},
+		{"0 order: north pole", 0, 0, 0, 0},
+		{"0 order: south pole", 0, math.Pi, 0, 8},
+		{"1 order: equator pixel vertex on the meridian goes east", 1, math.Pi / 2, 0, 17},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewColatLonCoordinate(tc.colatitude, tc.longitude).ToNestPixel(New(tc.order))
+			if r != tc.nest {
+				t.Errorf("Position %v,%v expected nest pixel %v, got %v instead", tc.colatitude, tc.longitude, tc.nest, r)
+			}
+		})
+	}
+}
+
+func TestSphereToNestPixelNearPole(t *testing.T) {
+	hp := New(NewHealpixOrder(MaxOrder()))
+	// the innermost ring of the polar cap lies well within 1e-9 radians of the pole at the maximum order, where
+	// 1 - cos(colatitude) has lost most of its precision
+	for _, nest := range []NestPixel{
+		NestPixel(hp.FacePixels() - 1),
+		NestPixel(4*hp.FacePixels() - 1),
+		NestPixel(8 * hp.FacePixels()),
+		NestPixel(11 * hp.FacePixels()),
+	} {
+		center := nest.ToSphereCoordinate(hp)
+		if r := center.ToNestPixel(hp); r != nest {
+			t.Errorf("Center %v of polar nest pixel %v expected to map back to itself, got %v instead", center, nest, r)
+		}
+	}
+}
+
+func TestFaceCoordinateSphereInverse(t *testing.T) {
+	hp := New(NewHealpixOrder(0))
+	rng := rand.New(rand.NewSource(28))
+	for i := 0; i < 10000; i++ {
+		pos := NewLatLonCoordinate(math.Asin(2*rng.Float64()-1), 2*math.Pi*rng.Float64())
+		rPos := pos.ToFaceCoordinate().ToSphereCoordinate(hp)
+		if !withinTolerance(pos.Colatitude(), rPos.Colatitude(), 1e-12) || !withinTolerance(pos.Longitude(), rPos.Longitude(), 1e-12) {
+			t.Fatalf("Position %v expected to survive face coordinate round trip, got %v instead", pos, rPos)
+		}
+		vec := pos.ToVector()
+		rVec := pos.ToFaceCoordinate().ToVector()
+		if math.Abs(vec.x-rVec.x) > 1e-12 || math.Abs(vec.y-rVec.y) > 1e-12 || math.Abs(vec.z-rVec.z) > 1e-12 {
+			t.Fatalf("Vector %v expected to survive face coordinate round trip, got %v instead", vec, rVec)
+		}
+		if vPos := vec.ToSphereCoordinate(hp); !withinTolerance(pos.Colatitude(), vPos.Colatitude(), 1e-12) || !withinTolerance(pos.Longitude(), vPos.Longitude(), 1e-12) {
+			t.Fatalf("Position %v expected to survive vector round trip, got %v instead", pos, vPos)
+		}
+	}
+}
+
+func TestFaceCoordinateAllOrders(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+	for i := 0; i < 10000; i++ {
+		pos := NewLatLonCoordinate(math.Asin(2*rng.Float64()-1), 2*math.Pi*rng.Float64())
+		fc := pos.ToFaceCoordinate()
+		vec := pos.ToVector()
+		for order := 0; order <= MaxOrder(); order += 3 {
+			hp := New(NewHealpixOrder(order))
+			expected := pos.ToNestPixel(hp)
+			if r := fc.ToNestPixel(hp); r != expected {
+				t.Fatalf("Order %d: face coordinate %v of %v expected nest pixel %v, got %v instead", order, fc, pos, expected, r)
+			}
+			if r := vec.ToNestPixel(hp); r != expected {
+				t.Fatalf("Order %d: vector %v of %v expected nest pixel %v, got %v instead", order, vec, pos, expected, r)
+			}
+		}
+	}
+}
+
+func TestFaceCoordinatePixelCenters(t *testing.T) {
+	hp := New(NewHealpixOrder(3))
+	nside := float64(hp.FaceSidePixels())
+	for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+		fp := nest.ToFacePixel(hp)
+		fc := NewFaceCoordinate(fp.face, (float64(fp.x)+0.5)/nside, (float64(fp.y)+0.5)/nside)
+		expected := nest.ToSphereCoordinate(hp)
+		r := fc.ToSphereCoordinate(hp)
+		if !withinTolerance(r.Colatitude(), expected.Colatitude(), 1e-12) || !withinTolerance(r.Longitude(), expected.Longitude(), 1e-12) {
+			t.Errorf("Center of nest pixel %v expected position %v, got %v instead", nest, expected, r)
+		}
+		if fc.ToNestPixel(hp) != nest {
+			t.Errorf("Center of nest pixel %v expected to be in the same pixel, got %v instead", nest, fc.ToNestPixel(hp))
+		}
+	}
+}
+
+func TestAngularDistance(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	testCases := []struct {
+		name     string
+		a        Where
+		b        Where
+		expected float64
+	}{
+		{"Quarter turn along equator", NewLatLonCoordinate(0, 0), NewLatLonCoordinate(0, math.Pi/2), math.Pi / 2},
+		{"Pole to equator", NewLatLonCoordinate(math.Pi/2, 0), NewLatLonCoordinate(0, 1), math.Pi / 2},
+		{"Antipodal", NewVector(1, 2, 3), NewVector(-1, -2, -3), math.Pi},
+		{"Tiny separation", NewLatLonCoordinate(0.3, 1), NewLatLonCoordinate(0.3+1e-12, 1), 1e-12},
+		{"Same pixel center", NestPixel(17), NestPixel(17).ToSphereCoordinate(hp), 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := AngularDistance(hp, tc.a, tc.b)
+			if !withinTolerance(r, tc.expected, 1e-6) && math.Abs(r-tc.expected) > 1e-15 {
+				t.Errorf("Expected %v, got %v instead", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestInitialBearing(t *testing.T) {
+	origin := NewLatLonCoordinate(0, 0)
+	testCases := []struct {
+		name     string
+		to       SphereCoordinate
+		expected float64
+	}{
+		{"North", NewLatLonCoordinate(0.1, 0), 0},
+		{"East", NewLatLonCoordinate(0, 0.1), math.Pi / 2},
+		{"South", NewLatLonCoordinate(-0.1, 0), math.Pi},
+		{"West", NewLatLonCoordinate(0, 2*math.Pi-0.1), 3 * math.Pi / 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := origin.InitialBearing(tc.to)
+			if math.Abs(r-tc.expected) > 1e-12 {
+				t.Errorf("Expected %v, got %v instead", tc.expected, r)
+			}
+			if rv := origin.ToVector().InitialBearing(tc.to.ToVector()); math.Abs(rv-r) > 1e-12 {
+				t.Errorf("Vector bearing expected %v, got %v instead", r, rv)
+			}
+		})
+	}
+}
+
+func TestDestinationInverse(t *testing.T) {
+	rng := rand.New(rand.NewSource(36))
+	for i := 0; i < 1000; i++ {
+		start := randomPosition(rng)
+		bearing := 2 * math.Pi * rng.Float64()
+		distance := 0.01 + 3*rng.Float64()
+		end := start.Destination(bearing, distance)
+		if r := start.AngularDistance(end); math.Abs(r-distance) > 1e-9 {
+			t.Fatalf("Distance from %v to %v expected %v, got %v instead", start, end, distance, r)
+		}
+		r := start.InitialBearing(end)
+		if d := math.Abs(math.Remainder(r-bearing, 2*math.Pi)); d > 1e-7 {
+			t.Fatalf("Bearing from %v to %v expected %v, got %v instead", start, end, bearing, r)
+		}
+		vend := start.ToVector().Destination(bearing, distance)
+		if vend.add(end.ToVector().scale(-1)).Length() > 1e-9 {
+			t.Fatalf("Vector destination expected %v, got %v instead", end.ToVector(), vend)
+		}
+	}
+}
+
+func TestMidpointAndInterpolate(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	for i := 0; i < 1000; i++ {
+		a := randomPosition(rng)
+		b := randomPosition(rng)
+		total := a.AngularDistance(b)
+		mid := a.Midpoint(b)
+		if math.Abs(a.AngularDistance(mid)-total/2) > 1e-9 || math.Abs(b.AngularDistance(mid)-total/2) > 1e-9 {
+			t.Fatalf("Midpoint %v of %v and %v not halfway", mid, a, b)
+		}
+		if a.Interpolate(b, 0.5).AngularDistance(mid) > 1e-9 {
+			t.Fatalf("Interpolate halfway expected %v, got %v instead", mid, a.Interpolate(b, 0.5))
+		}
+		if a.Interpolate(b, 0).AngularDistance(a) > 1e-9 || a.Interpolate(b, 1).AngularDistance(b) > 1e-9 {
+			t.Fatalf("Interpolate endpoints of %v and %v expected to match", a, b)
+		}
+		f := rng.Float64()
+		if math.Abs(a.AngularDistance(a.Interpolate(b, f))-f*total) > 1e-9 {
+			t.Fatalf("Interpolate fraction %v of %v and %v at wrong distance", f, a, b)
+		}
+	}
+}
+
+func TestMidpointAntipodalPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected midpoint of antipodal points to panic")
+		}
+	}()
+	NewVector(0, 0, 1).Midpoint(NewVector(0, 0, -1))
+}
+
+func TestCrossTrackDistance(t *testing.T) {
+	start := NewLatLonCoordinate(0, 0)
+	end := NewLatLonCoordinate(0, 1)
+	testCases := []struct {
+		name     string
+		p        SphereCoordinate
+		expected float64
+	}{
+		{"On the path", NewLatLonCoordinate(0, 0.5), 0},
+		{"Beyond the end", NewLatLonCoordinate(0, 3), 0},
+		{"Left of eastward travel", NewLatLonCoordinate(0.2, 0.5), -0.2},
+		{"Right of eastward travel", NewLatLonCoordinate(-0.3, 4), 0.3},
+		{"Pole", NewLatLonCoordinate(-math.Pi/2, 0), math.Pi / 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.p.CrossTrackDistance(start, end)
+			if math.Abs(r-tc.expected) > 1e-12 {
+				t.Errorf("Expected %v, got %v instead", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestFacePixelVertices(t *testing.T) {
+	hp := New(NewHealpixOrder(2))
+	for nest := NestPixel(0); nest < NestPixel(hp.Pixels()); nest++ {
+		center := nest.ToSphereCoordinate(hp)
+		vertices := nest.ToFacePixel(hp).Vertices(hp)
+		for _, v := range vertices {
+			if v.Latitude() > vertices[0].Latitude()+1e-12 || v.Latitude() < vertices[2].Latitude()-1e-12 {
+				t.Fatalf("Pixel %v: vertices %v expected north first and south third", nest, vertices)
+			}
+			if center.AngularDistance(v) > hp.MaxPixelRadius()+1e-12 {
+				t.Fatalf("Pixel %v: vertex %v farther from center than the max pixel radius", nest, v)
+			}
+		}
+		if center.InitialBearing(vertices[1]) < math.Pi || center.InitialBearing(vertices[3]) > math.Pi {
+			t.Fatalf("Pixel %v: vertices %v expected west second and east fourth", nest, vertices)
+		}
+	}
+}